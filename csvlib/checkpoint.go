@@ -0,0 +1,26 @@
+package csvlib
+
+import (
+	"io"
+	"iter"
+)
+
+// Checkpoint is a position in the input from which iteration may resume.
+// Offset is the byte offset of the next record to read and LineNumber is the line on which that record starts.
+type Checkpoint struct {
+	Offset     int64
+	LineNumber int
+}
+
+// NewIteratorFromCheckpoint returns an iterator with a DefaultBufferSize which starts reading from checkpoint.
+// Record line numbers and offsets continue from the checkpoint. If hasHeader is true the header is read from the start
+// of the input before seeking to the checkpoint.
+// Checkpoints are emitted using WithCheckpoints. See WithResume to resume other iterator types.
+func NewIteratorFromCheckpoint[T any](input io.ReadSeeker,
+	hasHeader bool,
+	checkpoint Checkpoint,
+	conversionFunc ParseFunc[T],
+	opts ...ReaderOption) (iter.Seq2[Record[T], error], error) {
+	opts = append([]ReaderOption{WithResume(checkpoint)}, opts...)
+	return iterator[T](input, hasHeader, DefaultBufferSize, nil, conversionFunc, opts)
+}
@@ -0,0 +1,90 @@
+package csvlib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIteratorFromCheckpoint(t *testing.T) {
+	csvData := sequenceCsv(t, 20)
+	parseFunc := func(fields []string) (string, error) {
+		return fields[0], nil
+	}
+
+	// interrupt the first iteration after 12 records, keeping the last checkpoint
+	var saved Checkpoint
+	var firstPass []Record[string]
+	iter, err := NewDefaultIterator(strings.NewReader(csvData), true, parseFunc, WithCheckpoints(5, func(cp Checkpoint) {
+		saved = cp
+	}))
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	for rec, err := range iter {
+		if err != nil {
+			t.Fatalf("iterator error = %v", err)
+		}
+		firstPass = append(firstPass, rec)
+		if len(firstPass) == 12 {
+			break
+		}
+	}
+
+	want := Checkpoint{Offset: firstPass[10].Offset, LineNumber: firstPass[10].LineNumber}
+	if saved != want {
+		t.Fatalf("checkpoint = %+v, want %+v", saved, want)
+	}
+
+	resumed, err := NewIteratorFromCheckpoint(strings.NewReader(csvData), true, saved, parseFunc)
+	if err != nil {
+		t.Fatalf("NewIteratorFromCheckpoint unexpected error %v", err)
+	}
+	var got []Record[string]
+	for rec, err := range resumed {
+		if err != nil {
+			t.Fatalf("iterator error = %v", err)
+		}
+		got = append(got, rec)
+	}
+
+	if len(got) != 10 {
+		t.Fatalf("resumed iterator yielded %d records, want 10", len(got))
+	}
+	if diff := cmp.Diff(firstPass[10:], got[:2]); diff != "" {
+		t.Errorf("resumed iterator found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestHeaderIterator_Resume(t *testing.T) {
+	csvData := "first_name,last_name\nJohn,Doe\nJane,Doe\n"
+	checkpoint := Checkpoint{Offset: int64(strings.Index(csvData, "Jane")), LineNumber: 3}
+
+	iter, err := NewHeaderIterator(strings.NewReader(csvData), nil, customRecordRowParseFunc, WithResume(checkpoint))
+	if err != nil {
+		t.Fatalf("NewHeaderIterator unexpected error %v", err)
+	}
+
+	var got []Record[CustomRecord]
+	for rec, err := range iter {
+		if err != nil {
+			t.Fatalf("iterator error = %v", err)
+		}
+		got = append(got, rec)
+	}
+	want := []Record[CustomRecord]{
+		{LineNumber: 3, Offset: checkpoint.Offset, Data: CustomRecord{FirstName: "Jane", LastName: "Doe"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("iterator found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithResume_RequiresSeeker(t *testing.T) {
+	input := failingReader{data: strings.NewReader("")}
+	_, err := NewDefaultIterator(input, true, customRecordParseFunc, WithResume(Checkpoint{}))
+	if err == nil {
+		t.Error("expected an error for an input which does not implement io.Seeker")
+	}
+}
@@ -0,0 +1,128 @@
+package csvlib
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"path"
+	"strings"
+)
+
+// ErrZipInput is returned when a zip archive is passed to an iterator which reads a single stream.
+// Zip archives are read with NewZipIterator.
+var ErrZipInput = errors.New("zip archive input requires NewZipIterator")
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	// bzip2BlockMagic follows the bzip2 header and block size, distinguishing bzip2 data from text beginning "BZh"
+	bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+	zipMagic        = []byte("PK\x03\x04")
+)
+
+// decompress returns a reader which decompresses input if its magic bytes identify gzip or bzip2 compressed data, or
+// zlib compressed data if detectZlib is true, otherwise input is returned. compressed is true if the input is
+// decompressed. An error wrapping ErrZipInput is returned for zip archives.
+func decompress(input *bufio.Reader, detectZlib bool) (reader io.Reader, compressed bool, err error) {
+	// Peek returns the available bytes along with an error for inputs shorter than the magic bytes
+	magic, _ := input.Peek(len(bzip2Magic) + 1 + len(bzip2BlockMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		reader, err = gzip.NewReader(input)
+	case bytes.HasPrefix(magic, bzip2Magic) && len(magic) > len(bzip2Magic) &&
+		magic[3] >= '1' && magic[3] <= '9' && bytes.Equal(magic[4:], bzip2BlockMagic):
+		reader = bzip2.NewReader(input)
+	case detectZlib && len(magic) >= 2 && magic[0] == 0x78 && (uint16(magic[0])<<8|uint16(magic[1]))%31 == 0 &&
+		(magic[1] == 0x01 || magic[1] == 0x9c || magic[1] == 0xda):
+		reader, err = zlib.NewReader(input)
+	case bytes.HasPrefix(magic, zipMagic):
+		return nil, false, ErrZipInput
+	default:
+		return input, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading compressed input %w", err)
+	}
+	return reader, true, nil
+}
+
+// WithoutDecompression disables the detection and decompression of compressed input.
+func WithoutDecompression() ReaderOption {
+	return option{reader: func(c *readerConfig) { c.disableDecompression = true }}
+}
+
+// WithZlib enables the detection and decompression of zlib compressed input.
+// zlib is not detected by default, as its two byte header is also the start of plain text such as "x\xda" in Latin-1.
+func WithZlib() ReaderOption {
+	return option{reader: func(c *readerConfig) { c.zlib = true }}
+}
+
+// WithGzip compresses the Writer's output using gzip.
+// Flush writes a gzip sync marker so that flushed records may be decompressed, and Close writes the gzip footer.
+func WithGzip() WriterOption {
+	return option{writer: func(c *writerConfig) { c.gzip = true }}
+}
+
+// NewZipIterator returns an iterator over the records of every CSV member in a zip archive, in archive order.
+// Members are CSV files if their name has a .csv extension, ignoring case. Each Record's Source is the name of the
+// member containing the record. If hasHeader is true, each member has a header.
+// Compressed members, such as .csv.gz files, are not read. WithCheckpoints and WithResume are not supported, as a
+// Checkpoint does not identify the member it was taken from.
+func NewZipIterator[T any](archive *zip.Reader,
+	hasHeader bool,
+	conversionFunc ParseFunc[T],
+	opts ...ReaderOption) (iter.Seq2[Record[T], error], error) {
+
+	if conversionFunc == nil {
+		err := errors.New("NewZipIterator: conversionFunc is required")
+		return nil, NewIterationError(0, err)
+	}
+	if archive == nil {
+		err := errors.New("NewZipIterator: archive is required")
+		return nil, NewIterationError(0, err)
+	}
+
+	cfg, err := newReaderConfig(DefaultBufferSize, opts)
+	if err == nil && (cfg.checkpoint != nil || cfg.checkpointFunc != nil) {
+		err = errors.New("checkpoints are not supported")
+	}
+	if err != nil {
+		return nil, NewIterationError(0, fmt.Errorf("NewZipIterator: %w", err))
+	}
+
+	return func(yield func(Record[T], error) bool) {
+		tracker := newRecordTracker(&cfg)
+		for _, member := range archive.File {
+			if member.FileInfo().IsDir() || !strings.EqualFold(path.Ext(member.Name), ".csv") {
+				continue
+			}
+
+			memberCfg := cfg
+			memberCfg.source = member.Name
+			input, err := member.Open()
+			if err != nil {
+				ie := NewIterationError(0, fmt.Errorf("error opening member %w", err))
+				ie.source = member.Name
+				yield(Record[T]{Source: member.Name}, ie)
+				return
+			}
+
+			for raw := range readRecords(input, hasHeader, tracker.headerFunc(nil), &memberCfg) {
+				record, err := parseRecord(raw, conversionFunc)
+				if !yieldTracked(yield, tracker, raw, record, err) {
+					input.Close()
+					return
+				}
+			}
+			input.Close()
+		}
+	}, nil
+}
@@ -0,0 +1,318 @@
+package csvlib
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func gzipData(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatalf("gzip write error %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("gzip close error %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zlibData(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatalf("zlib write error %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("zlib close error %v", err)
+	}
+	return buf.Bytes()
+}
+
+// bzip2Sample is "first_name,last_name\nJohn,Doe\n" compressed with bzip2.
+// The standard library does not provide a bzip2 compressor.
+var bzip2Sample = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xd0, 0xb5,
+	0xed, 0xfa, 0x00, 0x00, 0x07, 0x57, 0x80, 0x00, 0x10, 0x00, 0x04, 0x04,
+	0x10, 0x00, 0x00, 0xa3, 0x67, 0x9c, 0x00, 0x20, 0x00, 0x21, 0xa9, 0xa6,
+	0x9a, 0x03, 0xca, 0x34, 0x28, 0x69, 0xa6, 0x00, 0x2e, 0x0b, 0x3b, 0xc8,
+	0xb0, 0x2b, 0xad, 0x15, 0x38, 0x21, 0x20, 0x57, 0x63, 0xd2, 0x3e, 0x2e,
+	0xe4, 0x8a, 0x70, 0xa1, 0x21, 0xa1, 0x6b, 0xdb, 0xf4,
+}
+
+func TestIterator_Decompression(t *testing.T) {
+	csvData := sampleCsv(t, true)
+	var want []Record[CustomRecord]
+	plain, err := NewDefaultIterator(strings.NewReader(csvData), true, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	for rec, err := range plain {
+		if err != nil {
+			t.Fatalf("iterator error = %v", err)
+		}
+		want = append(want, rec)
+	}
+
+	tests := map[string]struct {
+		input []byte
+		opts  []ReaderOption
+	}{
+		"gzip": {input: gzipData(t, csvData)},
+		"zlib": {input: zlibData(t, csvData), opts: []ReaderOption{WithZlib()}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			iter, err := NewDefaultIterator(bytes.NewReader(tt.input), true, customRecordParseFunc, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewDefaultIterator unexpected error %v", err)
+			}
+			var got []Record[CustomRecord]
+			for rec, err := range iter {
+				if err != nil {
+					t.Fatalf("iterator error = %v", err)
+				}
+				got = append(got, rec)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("iterator found diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIterator_DecompressionBzip2(t *testing.T) {
+	iter, err := NewDefaultIterator(bytes.NewReader(bzip2Sample), true, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	var got []CustomRecord
+	for rec, err := range iter {
+		if err != nil {
+			t.Fatalf("iterator error = %v", err)
+		}
+		got = append(got, rec.Data)
+	}
+	want := []CustomRecord{{FirstName: "John", LastName: "Doe"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("iterator found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestIterator_PlainInputNotDecompressed(t *testing.T) {
+	// text which begins like bzip2 or zlib magic bytes is read as CSV, zlib is only detected WithZlib
+	for _, csvData := range []string{"BZh9,x\n1,2\n", "x\x9c,y\n1,2\n", "x\xda,y\n1,2\n", "x\x01,y\n1,2\n"} {
+		iter, err := NewDefaultIterator(strings.NewReader(csvData), false, func(fields []string) ([]string, error) {
+			return fields, nil
+		})
+		if err != nil {
+			t.Fatalf("NewDefaultIterator unexpected error %v", err)
+		}
+		records := 0
+		for _, err := range iter {
+			if err != nil {
+				t.Fatalf("iterator error = %v for input %q", err, csvData)
+			}
+			records++
+		}
+		if records != 2 {
+			t.Errorf("iterator yielded %d records for input %q, want 2", records, csvData)
+		}
+	}
+}
+
+func TestIterator_WithoutDecompression(t *testing.T) {
+	input := gzipData(t, sampleCsv(t, true))
+	iter, err := NewDefaultIterator(bytes.NewReader(input), false, func(fields []string) ([]string, error) {
+		return fields, nil
+	}, WithoutDecompression(), WithLazyQuotes(), WithFieldsPerRecord(-1))
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	for rec, err := range iter {
+		if err != nil {
+			t.Fatalf("iterator error = %v", err)
+		}
+		if !strings.HasPrefix(rec.Data[0], "\x1f\x8b") {
+			t.Errorf("iterator decompressed input, record = %q", rec.Data)
+		}
+		break
+	}
+}
+
+func TestIterator_CompressedInputErrors(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	if _, err := zw.Create("sample.csv"); err != nil {
+		t.Fatalf("zip create error %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close error %v", err)
+	}
+
+	tests := map[string]struct {
+		input   []byte
+		opts    []ReaderOption
+		wantErr string
+	}{
+		"zip archive": {
+			input:   archive.Bytes(),
+			wantErr: ErrZipInput.Error(),
+		},
+		"resume compressed input": {
+			input:   gzipData(t, sampleCsv(t, true)),
+			opts:    []ReaderOption{WithResume(Checkpoint{Offset: 21, LineNumber: 2})},
+			wantErr: "resuming compressed input from a checkpoint is not supported",
+		},
+		"corrupt gzip": {
+			input:   []byte{0x1f, 0x8b, 0x00},
+			wantErr: "error reading compressed input",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			iter, err := NewDefaultIterator(bytes.NewReader(tc.input), true, customRecordParseFunc, tc.opts...)
+			if err != nil {
+				t.Fatalf("NewDefaultIterator unexpected error %v", err)
+			}
+			var errs []error
+			for _, err := range iter {
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if len(errs) != 1 {
+				t.Fatalf("iterator returned %d errors, want 1: %v", len(errs), errs)
+			}
+			var ie *IterationError
+			if !errors.As(errs[0], &ie) {
+				t.Fatalf("error = %T, want *IterationError", errs[0])
+			}
+			if !strings.Contains(errs[0].Error(), tc.wantErr) {
+				t.Errorf("error = %v, want %q", errs[0], tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestZipIterator(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	members := []struct{ name, data string }{
+		{"first.csv", "first_name,last_name\nJohn,Doe\n"},
+		{"notes.txt", "not,a,csv\n"},
+		{"dir/", ""},
+		{"dir/SECOND.CSV", "first_name,last_name\nJane,Doe\nJim,Doe,Jr\n"},
+	}
+	for _, m := range members {
+		w, err := zw.Create(m.name)
+		if err != nil {
+			t.Fatalf("zip create error %v", err)
+		}
+		if _, err := w.Write([]byte(m.data)); err != nil {
+			t.Fatalf("zip write error %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close error %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader unexpected error %v", err)
+	}
+	iter, err := NewZipIterator(reader, true, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewZipIterator unexpected error %v", err)
+	}
+
+	var got []Record[CustomRecord]
+	var errs []error
+	for rec, err := range iter {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		got = append(got, rec)
+	}
+
+	want := []Record[CustomRecord]{
+		{LineNumber: 2, Offset: 21, Source: "first.csv", Data: CustomRecord{FirstName: "John", LastName: "Doe"}},
+		{LineNumber: 2, Offset: 21, Source: "dir/SECOND.CSV", Data: CustomRecord{FirstName: "Jane", LastName: "Doe"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("iterator found diff (-want +got):\n%s", diff)
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("iterator returned %d errors, want 1: %v", len(errs), errs)
+	}
+	var ie *IterationError
+	if !errors.As(errs[0], &ie) {
+		t.Fatalf("error = %T, want *IterationError", errs[0])
+	}
+	if ie.Source() != "dir/SECOND.CSV" || ie.LineNumber() != 3 {
+		t.Errorf("error source = %q line = %d, want %q line 3", ie.Source(), ie.LineNumber(), "dir/SECOND.CSV")
+	}
+	if !strings.Contains(ie.Error(), `of "dir/SECOND.CSV"`) {
+		t.Errorf("error = %v, want source in message", ie)
+	}
+}
+
+func TestNewZipIterator_Errors(t *testing.T) {
+	reader, err := zip.NewReader(bytes.NewReader(nil), 0)
+	if err == nil {
+		t.Fatal("zip.NewReader expected error for empty archive")
+	}
+	if _, err := NewZipIterator(reader, true, customRecordParseFunc); err == nil {
+		t.Error("NewZipIterator expected error for nil archive")
+	}
+	archive := &zip.Reader{}
+	if _, err := NewZipIterator[CustomRecord](archive, true, nil); err == nil {
+		t.Error("NewZipIterator expected error for nil conversionFunc")
+	}
+	if _, err := NewZipIterator(archive, true, customRecordParseFunc, WithResume(Checkpoint{Offset: 1})); err == nil {
+		t.Error("NewZipIterator expected error for WithResume")
+	}
+	if _, err := NewZipIterator(archive, true, customRecordParseFunc, WithCheckpoints(1, func(Checkpoint) {})); err == nil {
+		t.Error("NewZipIterator expected error for WithCheckpoints")
+	}
+}
+
+func TestWriter_Gzip(t *testing.T) {
+	var output bytes.Buffer
+	writer, err := NewWriter(&output, customRecordConvertFunc, WithGzip())
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+	if err := writer.WriteHeader([]string{"first_name", "last_name"}); err != nil {
+		t.Fatalf("WriteHeader unexpected error %v", err)
+	}
+	if err := writer.Write(CustomRecord{FirstName: "John", LastName: "Doe"}); err != nil {
+		t.Fatalf("Write unexpected error %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+
+	gr, err := gzip.NewReader(&output)
+	if err != nil {
+		t.Fatalf("gzip.NewReader unexpected error %v", err)
+	}
+	var got bytes.Buffer
+	if _, err := got.ReadFrom(gr); err != nil {
+		t.Fatalf("gzip read error %v", err)
+	}
+	if want := "first_name,last_name\nJohn,Doe\n"; got.String() != want {
+		t.Errorf("decompressed output = %q, want %q", got.String(), want)
+	}
+}
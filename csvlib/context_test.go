@@ -0,0 +1,67 @@
+package csvlib
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIteratorContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	iter, err := NewDefaultIteratorContext(ctx, strings.NewReader(sequenceCsv(t, 10)), true, slowParseFunc)
+	if err != nil {
+		t.Fatalf("NewDefaultIteratorContext unexpected error %v", err)
+	}
+
+	var lastErr error
+	count := 0
+	for _, err := range iter {
+		count++
+		if count == 3 {
+			cancel()
+		}
+		lastErr = err
+	}
+
+	if count != 4 {
+		t.Errorf("iterator yielded %d results, want 4", count)
+	}
+	var ie *IterationError
+	if !errors.As(lastErr, &ie) || !errors.Is(lastErr, context.Canceled) {
+		t.Fatalf("expected an IterationError wrapping context.Canceled, got %v", lastErr)
+	}
+	if ie.LineNumber() != 5 {
+		t.Errorf("IterationError line number = %d, want 5", ie.LineNumber())
+	}
+}
+
+func TestIteratorContext_Parallel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	iter, err := NewParallelIterator(strings.NewReader(sequenceCsv(t, 10)), true, 2, slowParseFunc, WithContext(ctx))
+	if err != nil {
+		t.Fatalf("NewParallelIterator unexpected error %v", err)
+	}
+	count := 0
+	for _, err := range iter {
+		count++
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	}
+	if count != 1 {
+		t.Errorf("iterator yielded %d results, want 1", count)
+	}
+}
+
+func TestIteratorContext_NilContext(t *testing.T) {
+	var ctx context.Context
+	_, err := NewIteratorContext(ctx, strings.NewReader(""), true, DefaultBufferSize, slowParseFunc)
+	if err == nil {
+		t.Error("expected an error for a nil context")
+	}
+}
@@ -3,11 +3,15 @@ package csvlib
 
 import (
 	"bufio"
+	"compress/gzip"
+	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
 	"iter"
+	"strings"
+	"time"
 )
 
 // DefaultBufferSize is the default buffer size used for reading records.
@@ -21,6 +25,14 @@ type baseError struct {
 	lineNumber int
 	// operation specifies the process iteration, record parsing, etc. which received an error.
 	operation string
+	// column is the name of the CSV column where the error occurred, if applicable.
+	column string
+	// offset is the byte offset in the input at which reading the record began.
+	offset int64
+	// raw contains the raw record fields, if available.
+	raw []string
+	// source is the name of the input containing the record, if applicable.
+	source string
 	// cause is the underlying error if available.
 	cause error
 }
@@ -37,12 +49,53 @@ func (b *baseError) format(operation string) string {
 		message = fmt.Sprintf("%s error", operation)
 	}
 
+	if b.source != "" {
+		message += fmt.Sprintf(" of %q", b.source)
+	}
+
+	if b.column != "" {
+		message += fmt.Sprintf(" column %q", b.column)
+	}
+
 	if b.cause != nil {
 		message += fmt.Sprintf(" %v", b.cause)
 	}
 	return message
 }
 
+// LineNumber returns the line number where the error occurred, or 0 if the error is not related to a line.
+func (b *baseError) LineNumber() int {
+	return b.lineNumber
+}
+
+// Column returns the name of the column where the error occurred, or "" if the error is not related to a column.
+func (b *baseError) Column() string {
+	return b.column
+}
+
+// Offset returns the byte offset in the input at which reading the record began.
+func (b *baseError) Offset() int64 {
+	return b.offset
+}
+
+// Source returns the name of the input containing the record, if the iterator reads multiple inputs.
+func (b *baseError) Source() string {
+	return b.source
+}
+
+// Raw returns the raw record fields, if the iterator was created WithRawFields and the fields are available.
+func (b *baseError) Raw() []string {
+	return b.raw
+}
+
+// setPosition sets the input position of the record which raised the error.
+func (b *baseError) setPosition(raw rawRecord) {
+	b.lineNumber = raw.lineNumber
+	b.offset = raw.offset
+	b.raw = raw.raw
+	b.source = raw.source
+}
+
 // IterationError is returned when an error occurs during2 CSV file iteration.
 type IterationError struct {
 	*baseError
@@ -82,9 +135,47 @@ func NewParseError(lineNumber int, cause error) *ParseError {
 	return &ParseError{baseError: &baseError{lineNumber: lineNumber, cause: cause}}
 }
 
+// isRecordError reports whether err is specific to a single record, such as a malformed record, a ParseError or a
+// ValidationError, rather than an error which prevents further iteration.
+func isRecordError(err error) bool {
+	var ie *IterationError
+	if !errors.As(err, &ie) {
+		return true
+	}
+	var pe *csv.ParseError
+	return errors.As(err, &pe)
+}
+
+// NewColumnParseError returns a ParseError for a specific column with the specified context information.
+func NewColumnParseError(lineNumber int, column string, cause error) *ParseError {
+	return &ParseError{baseError: &baseError{lineNumber: lineNumber, column: column, cause: cause}}
+}
+
+// newRecordParseError returns a ParseError for a ParseFunc error raised parsing raw.
+// ParseErrors returned by a ParseFunc without a line number are assigned the record's position rather than wrapped.
+func newRecordParseError(raw rawRecord, err error) *ParseError {
+	pe, ok := err.(*ParseError)
+	if !ok || pe.lineNumber != 0 {
+		pe = NewParseError(0, err)
+	}
+	pe.setPosition(raw)
+	return pe
+}
+
 // Record encapsulates a csv record including the record's line number and associated data.
+// LineNumber is the physical line on which the record starts, which accounts for quoted fields containing line
+// breaks.
+// Offset is the byte offset in the input at which reading the record began. Seeking to Offset and reading a record
+// returns the same record, unless the input is compressed or transcoded from another character encoding. In that case
+// Offset is the byte offset in the decompressed, UTF-8 encoded stream, and cannot be used to seek within the input.
+// Raw contains the raw record fields if the iterator was created WithRawFields.
+// Source is the name of the input containing the record for iterators which read multiple inputs, such as
+// NewZipIterator.
 type Record[T any] struct {
 	LineNumber int
+	Offset     int64
+	Raw        []string
+	Source     string
 	Data       T
 }
 
@@ -94,8 +185,9 @@ type ParseFunc[T any] func([]string) (T, error)
 // NewDefaultIterator returns an iterator with a DefaultBufferSize.
 func NewDefaultIterator[T any](input io.Reader,
 	hasHeader bool,
-	conversionFunc ParseFunc[T]) (iter.Seq2[Record[T], error], error) {
-	return iterator[T](input, hasHeader, DefaultBufferSize, conversionFunc)
+	conversionFunc ParseFunc[T],
+	opts ...ReaderOption) (iter.Seq2[Record[T], error], error) {
+	return iterator[T](input, hasHeader, DefaultBufferSize, nil, conversionFunc, opts)
 }
 
 // NewIterator returns a buffered iterator with a configurable buffer size.
@@ -103,67 +195,345 @@ func NewDefaultIterator[T any](input io.Reader,
 func NewIterator[T any](input io.Reader,
 	hasHeader bool,
 	bufferSize int,
-	conversionFunc ParseFunc[T]) (iter.Seq2[Record[T], error], error) {
-	return iterator[T](input, hasHeader, bufferSize, conversionFunc)
+	conversionFunc ParseFunc[T],
+	opts ...ReaderOption) (iter.Seq2[Record[T], error], error) {
+	return iterator[T](input, hasHeader, bufferSize, nil, conversionFunc, opts)
+}
+
+// NewDefaultIteratorContext returns an iterator with a DefaultBufferSize which stops when ctx is done.
+// See WithContext for cancellation behavior.
+func NewDefaultIteratorContext[T any](ctx context.Context,
+	input io.Reader,
+	hasHeader bool,
+	conversionFunc ParseFunc[T],
+	opts ...ReaderOption) (iter.Seq2[Record[T], error], error) {
+	opts = append([]ReaderOption{WithContext(ctx)}, opts...)
+	return iterator[T](input, hasHeader, DefaultBufferSize, nil, conversionFunc, opts)
+}
+
+// NewIteratorContext returns a buffered iterator with a configurable buffer size which stops when ctx is done.
+// See WithContext for cancellation behavior.
+func NewIteratorContext[T any](ctx context.Context,
+	input io.Reader,
+	hasHeader bool,
+	bufferSize int,
+	conversionFunc ParseFunc[T],
+	opts ...ReaderOption) (iter.Seq2[Record[T], error], error) {
+	opts = append([]ReaderOption{WithContext(ctx)}, opts...)
+	return iterator[T](input, hasHeader, bufferSize, nil, conversionFunc, opts)
 }
 
 // iterator returns iter.Seq2[Record[T], error].
 // Data from the underlying CSV file is read using a buffered csv.Reader and is mapped to T using a ParseFunc.
 // Custom buffer sizes may be specified if customBufferSize is set to a value > DefaultBufferSize.
+// headerFunc, if not nil, receives the header record when hasHeader is true.
+// opts configure the CSV dialect and iteration behavior.
 func iterator[T any](
 	input io.Reader,
 	hasHeader bool,
 	customBufferSize int,
-	conversionFunc ParseFunc[T]) (iter.Seq2[Record[T], error], error) {
+	headerFunc func([]string) error,
+	conversionFunc ParseFunc[T],
+	opts []ReaderOption) (iter.Seq2[Record[T], error], error) {
 
 	if conversionFunc == nil {
 		err := errors.New("iterator: csvToExampleRecord is required")
 		return nil, NewIterationError(0, err)
 	}
 
-	bufSize := DefaultBufferSize
-	if customBufferSize > DefaultBufferSize {
-		bufSize = customBufferSize
+	cfg, err := newReaderConfig(customBufferSize, opts)
+	if err == nil {
+		err = cfg.validateInput(input)
+	}
+	if err != nil {
+		return nil, NewIterationError(0, fmt.Errorf("iterator: %w", err))
 	}
-	reader := csv.NewReader(bufio.NewReaderSize(input, bufSize))
 
 	return func(yield func(Record[T], error) bool) {
+		tracker := newRecordTracker(&cfg)
+		for raw := range readRecords(input, hasHeader, tracker.headerFunc(headerFunc), &cfg) {
+			record, err := parseRecord(raw, conversionFunc)
+			if !yieldTracked(yield, tracker, raw, record, err) {
+				return
+			}
+		}
+	}, nil
+}
+
+// rawRecord is a CSV record read from the input prior to parsing.
+type rawRecord struct {
+	lineNumber int
+	offset     int64
+	fields     []string
+	// raw is fields if raw fields are included in records, otherwise nil.
+	raw []string
+	// err is the IterationError or ValidationError raised reading the record, if any.
+	err error
+	// terminal is true if err ends iteration, such as a header or cancellation error.
+	terminal bool
+	// next is the checkpoint for the record following this record.
+	next Checkpoint
+	// source is the name of the input containing the record, if applicable.
+	source string
+}
+
+// decodedRecord is a record's fields along with the lines it spans, relative to the start of the decoder's input.
+type decodedRecord struct {
+	fields   []string
+	line     int
+	lastLine int
+}
+
+// recordDecoder splits decoded input into records.
+type recordDecoder interface {
+	// decode returns the next record, or io.EOF. A record with an error and a line number is malformed, and reading may
+	// continue with the next record. Other errors are not recoverable.
+	decode() (decodedRecord, error)
+	// inputOffset returns the offset of the end of the last record read, relative to the start of the input.
+	inputOffset() int64
+}
+
+// csvDecoder is a recordDecoder for delimited input.
+type csvDecoder struct {
+	reader *csv.Reader
+}
+
+// decode implements recordDecoder.
+func (d csvDecoder) decode() (decodedRecord, error) {
+	csvFields, err := d.reader.Read()
+	record := decodedRecord{fields: csvFields}
+	if err != nil {
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			record.line, record.lastLine = pe.StartLine, pe.Line
+		}
+		return record, err
+	}
+
+	line, _ := d.reader.FieldPos(0)
+	lastLine, _ := d.reader.FieldPos(len(csvFields) - 1)
+	record.line = line
+	record.lastLine = lastLine + strings.Count(csvFields[len(csvFields)-1], "\n")
+	return record, nil
+}
+
+// inputOffset implements recordDecoder.
+func (d csvDecoder) inputOffset() int64 {
+	return d.reader.InputOffset()
+}
+
+// inputStream is the CSV data read from an input after decompression and decoding.
+type inputStream struct {
+	reader io.Reader
+	// bomLength is the length of a UTF-8 byte order mark stripped from the input.
+	bomLength int64
+	// transform describes the decompression or transcoding applied to the input, or "" if data is read as is.
+	transform string
+}
+
+// openInput returns the inputStream for input, decompressing and decoding the input as configured.
+func openInput(input io.Reader, cfg *readerConfig) (inputStream, error) {
+	buffered := bufio.NewReaderSize(input, cfg.bufferSize)
+	stream := inputStream{reader: buffered}
+	if !cfg.disableDecompression {
+		reader, compressed, err := decompress(buffered, cfg.zlib)
+		if err != nil {
+			return stream, err
+		}
+		if compressed {
+			buffered = bufio.NewReaderSize(reader, cfg.bufferSize)
+			stream.transform = "compressed"
+		}
+	}
+
+	reader, bomLength, transcoded := decode(buffered, cfg.charset)
+	stream.reader = reader
+	stream.bomLength = bomLength
+	if transcoded && stream.transform == "" {
+		stream.transform = "transcoded"
+	}
+	return stream, nil
+}
+
+// readRecords returns an iter.Seq which reads rawRecords from input using a buffered csv.Reader.
+// The header record, if present, is passed to headerFunc rather than yielded.
+func readRecords(input io.Reader,
+	hasHeader bool,
+	headerFunc func([]string) error,
+	cfg *readerConfig) iter.Seq[rawRecord] {
+
+	return func(yield func(rawRecord) bool) {
+		stream, err := openInput(input, cfg)
+		if err != nil {
+			ie := NewIterationError(0, err)
+			ie.setPosition(rawRecord{lineNumber: 1, source: cfg.source})
+			yield(rawRecord{lineNumber: 1, source: cfg.source, err: ie, terminal: true})
+			return
+		}
+		decoder := cfg.newDecoder(stream.reader)
+		// baseOffset and baseLine position the reader within the input when resuming from a checkpoint, baseOffset
+		// includes a stripped byte order mark so that offsets may be used to seek within the input
+		baseOffset := stream.bomLength
+		baseLine := 0
+		// nextLine is the line where the next record is expected to start
+		nextLine := 1
+
+		// masker masks records once bound to the header, if configured
+		var masker *fieldMasker
+
+		// read returns the next rawRecord or io.EOF
+		read := func() (rawRecord, error) {
+			raw := rawRecord{lineNumber: nextLine, offset: baseOffset + decoder.inputOffset(), source: cfg.source}
+			decoded, err := decoder.decode()
+			if err == io.EOF {
+				return raw, err
+			}
+			raw.fields = decoded.fields
+			// records are masked by position before errors capture their fields, including malformed records
+			if masker != nil && raw.fields != nil {
+				raw.fields = masker.apply(raw.fields)
+			}
+			if cfg.rawFields {
+				raw.raw = raw.fields
+			}
+
+			if decoded.line > 0 {
+				raw.lineNumber = baseLine + decoded.line
+				nextLine = baseLine + decoded.lastLine + 1
+			}
+			if err != nil {
+				// errors without a line, such as errors from the underlying input, are not recoverable
+				raw.terminal = decoded.line == 0
+				ie := NewIterationError(0, err)
+				ie.setPosition(raw)
+				raw.err = ie
+			}
+			raw.next = Checkpoint{Offset: baseOffset + decoder.inputOffset(), LineNumber: nextLine}
+			return raw, nil
+		}
+
+		// terminate yields a terminal rawRecord for err at the current position
+		terminate := func(err error) {
+			raw := rawRecord{lineNumber: nextLine, offset: baseOffset + decoder.inputOffset(), source: cfg.source, terminal: true}
+			ie := NewIterationError(0, err)
+			ie.setPosition(raw)
+			raw.err = ie
+			yield(raw)
+		}
+
+		// validator validates records against the schema, if configured
+		var validator *schemaValidator
+		// columns names the fields of formula violations
+		var columns []string
+		// bind prepares header dependent record processing, header is nil if the input does not have a header
+		bind := func(header []string) error {
+			if cfg.masking != nil {
+				m, err := bindMasks(cfg.masking, header)
+				if err != nil {
+					return err
+				}
+				masker = m
+				if header != nil {
+					header = m.header(header)
+				}
+			}
+			columns = header
+			if header != nil && headerFunc != nil {
+				if err := headerFunc(header); err != nil {
+					return err
+				}
+			}
+			if cfg.schema != nil {
+				v, err := cfg.schema.bind(header)
+				if err != nil {
+					return err
+				}
+				validator = v
+			}
+			return nil
+		}
 
-		lineNumber := 0
 		if hasHeader {
-			lineNumber++
-			if _, err := reader.Read(); err != nil {
+			header, err := read()
+			if err != nil {
 				return
 			}
+			if header.err == nil {
+				if err := bind(header.fields); err != nil {
+					ie := NewIterationError(0, err)
+					ie.setPosition(header)
+					header.err = ie
+				}
+			}
+			if header.err != nil {
+				header.terminal = true
+				yield(header)
+				return
+			}
+		} else if err := bind(nil); err != nil {
+			terminate(err)
+			return
+		}
+
+		if cp := cfg.checkpoint; cp != nil && cp.Offset > 0 {
+			if stream.transform != "" {
+				terminate(fmt.Errorf("resuming %s input from a checkpoint is not supported", stream.transform))
+				return
+			}
+			if _, err := input.(io.Seeker).Seek(cp.Offset, io.SeekStart); err != nil {
+				terminate(fmt.Errorf("error seeking to checkpoint %w", err))
+				return
+			}
+			decoder = cfg.newDecoder(bufio.NewReaderSize(input, cfg.bufferSize))
+			baseOffset = cp.Offset
+			baseLine = max(cp.LineNumber, 1) - 1
+			nextLine = baseLine + 1
 		}
 
 		for {
-			lineNumber++
-			csvFields, err := reader.Read()
-			// handle csv read errors
+			if err := cfg.ctx.Err(); err != nil {
+				terminate(fmt.Errorf("iteration cancelled: %w", err))
+				return
+			}
+			raw, err := read()
 			if err != nil {
-				if err == io.EOF {
-					return
+				return
+			}
+			if raw.err == nil && (validator != nil || cfg.detectFormulas) {
+				var violations []Violation
+				if validator != nil {
+					violations = validator.validate(raw.fields)
 				}
-				// general iteration error
-				if !yield(Record[T]{LineNumber: lineNumber}, NewIterationError(lineNumber, err)) {
-					return
+				if cfg.detectFormulas {
+					violations = append(violations, formulaViolations(raw.fields, columns)...)
 				}
-				continue
-			}
-			convertedData, err := conversionFunc(csvFields)
-			// record conversion error
-			if err != nil {
-				if !yield(Record[T]{LineNumber: lineNumber}, NewParseError(lineNumber, err)) {
-					return
+				if len(violations) > 0 {
+					ve := NewValidationError(0, violations)
+					ve.setPosition(raw)
+					raw.err = ve
 				}
-				continue
 			}
-			if !yield(Record[T]{LineNumber: lineNumber, Data: convertedData}, nil) {
+			if !yield(raw) || raw.terminal {
 				return
 			}
 		}
-	}, nil
+	}
+}
+
+// parseRecord maps a rawRecord to a Record[T] using conversionFunc.
+// An error is returned if the rawRecord contains an IterationError, or if conversionFunc returns an error.
+func parseRecord[T any](raw rawRecord, conversionFunc ParseFunc[T]) (Record[T], error) {
+	record := Record[T]{LineNumber: raw.lineNumber, Offset: raw.offset, Raw: raw.raw, Source: raw.source}
+	if raw.err != nil {
+		return record, raw.err
+	}
+	convertedData, err := conversionFunc(raw.fields)
+	// record conversion error
+	if err != nil {
+		return record, newRecordParseError(raw, err)
+	}
+	record.Data = convertedData
+	return record, nil
 }
 
 // ConvertFunc converts type T to a []string record for CSV writing output.
@@ -171,55 +541,280 @@ type ConvertFunc[T any] func(T) ([]string, error)
 
 // Writer writes csv records to an output file.
 // convertFunc is used to convert type T to []string for CSV writer output.
+// header is the header record derived for T, if available.
 type Writer[T any] struct {
 	convertFunc  ConvertFunc[T]
 	outputWriter *csv.Writer
+	// buffer is the buffered writer shared with outputWriter. Records which bypass outputWriter, such as quote-all
+	// records, are written directly to buffer.
+	buffer *bufio.Writer
+	// gzipWriter compresses output if the Writer was created WithGzip.
+	gzipWriter *gzip.Writer
+	// counter counts the bytes flushed from buffer, before compression.
+	counter *countingWriter
+	// file is the temporary file replacing the target path if the Writer was created with NewFileWriter.
+	file   *atomicFile
+	config writerConfig
+	header []string
+	stats  WriterStats
+	// unflushed is the number of records written since the last flush.
+	unflushed int
+	lastFlush time.Time
+	// created is the time the Writer was created, for stats logging.
+	created time.Time
+	// masker masks records if the Writer was created WithMasking, once bound to the header or to field positions.
+	masker *fieldMasker
+}
+
+// Header returns the header record derived for T by NewStructWriter, or nil if the Writer was not created from
+// struct tags.
+func (w *Writer[T]) Header() []string {
+	return w.header
 }
 
 // Flush writes the current buffer to the output
 func (w *Writer[T]) Flush() {
 	w.outputWriter.Flush()
+	if w.gzipWriter != nil {
+		w.gzipWriter.Flush()
+	}
+	w.unflushed = 0
+	w.lastFlush = time.Now()
 }
 
 // Close flushes remaining data to the writer and closes related resources.
+// The output target itself is not closed, unless the Writer was created with NewFileWriter. In that case the written
+// file replaces the target path, or is removed if the data could not be written.
+// The Writer's WriterStats are logged if the Writer was created WithStatsLogging.
 func (w *Writer[T]) Close() error {
+	if w.file != nil && w.file.done {
+		return nil
+	}
+	err := w.close()
+	if w.file != nil {
+		if err != nil {
+			w.file.abort()
+		} else if commitErr := w.file.commit(); commitErr != nil {
+			err = fmt.Errorf("Writer.Close: %w", commitErr)
+		}
+	}
+	w.logStats(err)
+	return err
+}
+
+// close flushes remaining data and closes the gzip writer, if applicable.
+func (w *Writer[T]) close() error {
 	w.outputWriter.Flush()
 	err := w.outputWriter.Error()
 	if err != nil {
 		return fmt.Errorf("Writer.Close: error flushing data %w", err)
 	}
+	if w.gzipWriter != nil {
+		if err := w.gzipWriter.Close(); err != nil {
+			return fmt.Errorf("Writer.Close: error closing gzip writer %w", err)
+		}
+	}
+	return nil
+}
+
+// Abort discards the output of a Writer created with NewFileWriter, leaving the target path unchanged.
+// Abort has no effect on other Writers, or after Close.
+func (w *Writer[T]) Abort() error {
+	if w.file == nil {
+		return nil
+	}
+	if err := w.file.abort(); err != nil {
+		return fmt.Errorf("Writer.Abort: %w", err)
+	}
 	return nil
 }
 
 // Write writes the csv record to the output.
 func (w *Writer[T]) Write(inputRecord T) error {
-	csvFields, err := w.convertFunc(inputRecord)
+	csvFields, err := w.convert(inputRecord)
 	if err != nil {
-		return fmt.Errorf("Writer.Write: error converting %w", err)
+		return fmt.Errorf("Writer.Write: %w", err)
+	}
+	if err := w.writeRow(csvFields); err != nil {
+		return fmt.Errorf("Writer.Write: %w", err)
 	}
+	return nil
+}
 
-	err = w.outputWriter.Write(csvFields)
+// convert converts inputRecord to csv fields, counting conversion failures.
+func (w *Writer[T]) convert(inputRecord T) ([]string, error) {
+	csvFields, err := w.convertFunc(inputRecord)
 	if err != nil {
-		return fmt.Errorf("Writer.Write: error writing data %w", err)
+		w.stats.ConversionErrors++
+		return nil, fmt.Errorf("error converting %w", err)
+	}
+	return csvFields, nil
+}
+
+// writeRow masks and writes the converted csv fields of a record and applies the FlushPolicy.
+func (w *Writer[T]) writeRow(csvFields []string) error {
+	if w.config.masking != nil {
+		if w.masker == nil {
+			masker, err := bindMasks(w.config.masking, nil)
+			if err != nil {
+				return fmt.Errorf("error masking data %w", err)
+			}
+			w.masker = masker
+		}
+		csvFields = w.masker.apply(csvFields)
+	}
+	if err := w.writeRecord(csvFields); err != nil {
+		return fmt.Errorf("error writing data %w", err)
+	}
+	w.stats.Rows++
+	if err := w.applyFlushPolicy(); err != nil {
+		return fmt.Errorf("error flushing data %w", err)
 	}
 	return nil
 }
 
 // WriteHeader writes a header to the output csv file.
+// If the Writer was created WithMasking, the masking rules are bound to the header's columns.
 func (w *Writer[T]) WriteHeader(headerRecord []string) error {
-	err := w.outputWriter.Write(headerRecord)
+	if w.config.masking != nil {
+		if w.masker == nil {
+			masker, err := bindMasks(w.config.masking, headerRecord)
+			if err != nil {
+				return fmt.Errorf("Writer.WriteHeader: %w", err)
+			}
+			w.masker = masker
+		}
+		headerRecord = w.masker.header(headerRecord)
+	}
+	err := w.writeRecord(headerRecord)
 	if err != nil {
 		return fmt.Errorf("Writer.WriteHeade: error writing header %w", err)
 	}
+	if err := w.applyFlushPolicy(); err != nil {
+		return fmt.Errorf("Writer.WriteHeader: error flushing header %w", err)
+	}
 	return nil
 }
 
+// writeRecord writes csvFields to the output using the Writer's dialect.
+func (w *Writer[T]) writeRecord(csvFields []string) error {
+	escaped := false
+	if w.config.formulaEscaping != nil {
+		csvFields, escaped = escapeFormulas(csvFields)
+	}
+	if w.config.layout != nil {
+		return w.writeFixedRecord(csvFields)
+	}
+	if w.config.quoteAll {
+		return w.writeQuotedRecord(csvFields, nil)
+	}
+	if escaped && *w.config.formulaEscaping == EscapeWithQuotes {
+		return w.writeQuotedRecord(csvFields, isFormulaEscaped)
+	}
+	return w.outputWriter.Write(csvFields)
+}
+
+// isFormulaEscaped reports whether field was escaped by escapeFormulas.
+func isFormulaEscaped(field string) bool {
+	return strings.HasPrefix(field, formulaPrefix) && isFormula(field[len(formulaPrefix):])
+}
+
+// writeQuotedRecord writes csvFields to the output with every field quoted, or, if quote is not nil, with the fields
+// for which quote returns true, or which require quoting, quoted.
+// Quotes and line breaks within fields are written following the same rules as csv.Writer.
+func (w *Writer[T]) writeQuotedRecord(csvFields []string, quote func(string) bool) error {
+	for i, field := range csvFields {
+		if i > 0 {
+			w.buffer.WriteRune(w.config.comma)
+		}
+		if quote != nil && !quote(field) && !fieldNeedsQuotes(field, w.config.comma) {
+			w.buffer.WriteString(field)
+			continue
+		}
+		w.buffer.WriteByte('"')
+		for _, r := range field {
+			switch {
+			case r == '"':
+				w.buffer.WriteString(`""`)
+			case r == '\r' && w.config.useCRLF:
+			case r == '\n' && w.config.useCRLF:
+				w.buffer.WriteString("\r\n")
+			default:
+				w.buffer.WriteRune(r)
+			}
+		}
+		w.buffer.WriteByte('"')
+	}
+
+	var err error
+	if w.config.useCRLF {
+		_, err = w.buffer.WriteString("\r\n")
+	} else {
+		err = w.buffer.WriteByte('\n')
+	}
+	return err
+}
+
 // NewWriter creates a new Writer which writes records of type T to an output target.
-func NewWriter[T any](output io.Writer, convertFunc ConvertFunc[T]) (Writer[T], error) {
+// opts configure the CSV dialect and writing behavior.
+func NewWriter[T any](output io.Writer, convertFunc ConvertFunc[T], opts ...WriterOption) (Writer[T], error) {
 	if convertFunc == nil {
 		return Writer[T]{}, errors.New("NewWriter: convertFunc is required")
 	}
 
-	writer := csv.NewWriter(bufio.NewWriterSize(output, DefaultBufferSize))
-	return Writer[T]{convertFunc: convertFunc, outputWriter: writer}, nil
+	cfg, err := newWriterConfig(opts)
+	if err != nil {
+		return Writer[T]{}, fmt.Errorf("NewWriter: %w", err)
+	}
+
+	return newWriter(output, convertFunc, cfg), nil
+}
+
+// newWriter creates a new Writer for a validated writerConfig.
+func newWriter[T any](output io.Writer, convertFunc ConvertFunc[T], cfg writerConfig) Writer[T] {
+	var gzipWriter *gzip.Writer
+	if cfg.gzip {
+		gzipWriter = gzip.NewWriter(output)
+		output = gzipWriter
+	}
+
+	counter := &countingWriter{output: output}
+
+	// csv.NewWriter reuses a *bufio.Writer of at least its default size, so buffer and writer share the same buffer.
+	buffer := bufio.NewWriterSize(counter, cfg.bufferSize)
+	if cfg.bom {
+		buffer.Write(utf8BOM)
+	}
+	writer := csv.NewWriter(buffer)
+	writer.Comma = cfg.comma
+	writer.UseCRLF = cfg.useCRLF
+	now := time.Now()
+	return Writer[T]{
+		convertFunc:  convertFunc,
+		outputWriter: writer,
+		buffer:       buffer,
+		gzipWriter:   gzipWriter,
+		counter:      counter,
+		config:       cfg,
+		lastFlush:    now,
+		created:      now,
+	}
+}
+
+// bytesWritten returns the number of bytes written, including buffered bytes, before compression.
+func (w *Writer[T]) bytesWritten() int64 {
+	return w.counter.n + int64(w.buffer.Buffered())
+}
+
+// countingWriter counts the bytes written to its output.
+type countingWriter struct {
+	output io.Writer
+	n      int64
+}
+
+// Write implements io.Writer.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.output.Write(p)
+	c.n += int64(n)
+	return n, err
 }
@@ -86,16 +86,16 @@ func TestIterator_StringSlice(t *testing.T) {
 		"has-header": {
 			hasHeader: true,
 			want: []Record[[]string]{
-				{LineNumber: 2, Data: []string{"John", "Doe"}},
-				{LineNumber: 3, Data: []string{"Jane", "Doe"}},
+				{LineNumber: 2, Offset: 25, Data: []string{"John", "Doe"}},
+				{LineNumber: 3, Offset: 38, Data: []string{"Jane", "Doe"}},
 			},
 			wantErr: false,
 		},
 		"has-no-header": {
 			hasHeader: false,
 			want: []Record[[]string]{
-				{LineNumber: 1, Data: []string{"John", "Doe"}},
-				{LineNumber: 2, Data: []string{"Jane", "Doe"}},
+				{LineNumber: 1, Offset: 0, Data: []string{"John", "Doe"}},
+				{LineNumber: 2, Offset: 13, Data: []string{"Jane", "Doe"}},
 			},
 			wantErr: false,
 		},
@@ -115,6 +115,7 @@ func TestIterator_CustomType(t *testing.T) {
 			want: []Record[CustomRecord]{
 				{
 					LineNumber: 2,
+					Offset:     25,
 					Data: CustomRecord{
 						FirstName: "John",
 						LastName:  "Doe",
@@ -122,6 +123,7 @@ func TestIterator_CustomType(t *testing.T) {
 				},
 				{
 					LineNumber: 3,
+					Offset:     38,
 					Data: CustomRecord{
 						FirstName: "Jane",
 						LastName:  "Doe",
@@ -135,6 +137,7 @@ func TestIterator_CustomType(t *testing.T) {
 			want: []Record[CustomRecord]{
 				{
 					LineNumber: 1,
+					Offset:     0,
 					Data: CustomRecord{
 						FirstName: "John",
 						LastName:  "Doe",
@@ -142,6 +145,7 @@ func TestIterator_CustomType(t *testing.T) {
 				},
 				{
 					LineNumber: 2,
+					Offset:     13,
 					Data: CustomRecord{
 						FirstName: "Jane",
 						LastName:  "Doe",
@@ -216,3 +220,86 @@ func TestWriter(t *testing.T) {
 		t.Errorf("Writer did not write expected contents (-want +got):\n%s", diff)
 	}
 }
+
+func TestIterator_Positions(t *testing.T) {
+	// the second record spans lines 3-4, the third record has the wrong number of fields
+	csvData := "id,note\n1,a\n2,\"multi\nline\"\n3\n4,d\n"
+
+	parseFunc := func(fields []string) (string, error) {
+		return fields[0], nil
+	}
+	iter, err := NewDefaultIterator(strings.NewReader(csvData), true, parseFunc, WithRawFields())
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+
+	type position struct {
+		Line   int
+		Offset int64
+		Raw    []string
+		Err    bool
+	}
+	want := []position{
+		{Line: 2, Offset: 8, Raw: []string{"1", "a"}},
+		{Line: 3, Offset: 12, Raw: []string{"2", "multi\nline"}},
+		{Line: 5, Offset: 27, Raw: []string{"3"}, Err: true},
+		{Line: 6, Offset: 29, Raw: []string{"4", "d"}},
+	}
+
+	var got []position
+	for rec, err := range iter {
+		got = append(got, position{Line: rec.LineNumber, Offset: rec.Offset, Raw: rec.Raw, Err: err != nil})
+
+		var ie *IterationError
+		if err != nil && (!errors.As(err, &ie) || ie.LineNumber() != rec.LineNumber || ie.Offset() != rec.Offset ||
+			!cmp.Equal(ie.Raw(), rec.Raw)) {
+			t.Errorf("IterationError position does not match record position: %v", err)
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("iterator found diff (-want +got):\n%s", diff)
+	}
+
+	// seeking to a record's offset reads the same record
+	seekIter, err := NewDefaultIterator(strings.NewReader(csvData[want[1].Offset:]), false, parseFunc)
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	for rec, err := range seekIter {
+		if err != nil || rec.Data != "2" {
+			t.Errorf("expected record 2 at offset %d, got %q, %v", want[1].Offset, rec.Data, err)
+		}
+		break
+	}
+}
+
+// failingReader returns an error after its data has been read.
+type failingReader struct {
+	data *strings.Reader
+}
+
+func (f failingReader) Read(p []byte) (int, error) {
+	if f.data.Len() == 0 {
+		return 0, errors.New("test case read error")
+	}
+	return f.data.Read(p)
+}
+
+func TestIterator_ReadErrorStopsIteration(t *testing.T) {
+	input := failingReader{data: strings.NewReader(sampleCsv(t, true) + "\n")}
+	iter, err := NewDefaultIterator(input, true, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+
+	count := 0
+	var lastErr error
+	for _, err := range iter {
+		count++
+		lastErr = err
+	}
+	var ie *IterationError
+	if count != 3 || !errors.As(lastErr, &ie) {
+		t.Errorf("expected 2 records followed by an IterationError, got %d results ending with %v", count, lastErr)
+	}
+}
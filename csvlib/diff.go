@@ -0,0 +1,378 @@
+package csvlib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ChangeType classifies a RowDiff.
+type ChangeType int
+
+const (
+	// Added rows are only present in the new input.
+	Added ChangeType = iota
+	// Removed rows are only present in the old input.
+	Removed
+	// Changed rows are present in both inputs with different values.
+	Changed
+)
+
+// String returns the ChangeType name.
+func (ct ChangeType) String() string {
+	switch ct {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return fmt.Sprintf("ChangeType(%d)", int(ct))
+}
+
+// ColumnChange is a column value which differs between the old and new inputs.
+type ColumnChange struct {
+	Column string
+	Old    string
+	New    string
+}
+
+// RowDiff describes a row which was added, removed or changed between the old and new inputs.
+type RowDiff struct {
+	Change ChangeType
+	// Key contains the row's key column values.
+	Key []string
+	// OldLine and NewLine are the row's line numbers in the old and new inputs, or 0 if the row is absent.
+	OldLine int
+	NewLine int
+	// Old and New are the row's fields in the old and new inputs, or nil if the row is absent.
+	Old []string
+	New []string
+	// Changes lists the columns with different values for Changed rows, in new header order.
+	Changes []ColumnChange
+}
+
+// keyedRow is a row's fields along with its key.
+type keyedRow struct {
+	fields []string
+	key    []string
+}
+
+// keyString returns the row's key as a string which orders keys as SortFile orders StringType key columns.
+func (r keyedRow) keyString() string {
+	return strings.Join(r.key, "\x00")
+}
+
+// keyedRows returns an iterator over the rows of input with their key column values. The header is available once
+// iteration has started.
+func keyedRows(input io.Reader, keyColumns []string, opts []ReaderOption) (iter.Seq2[Record[keyedRow], error],
+	*[]string, error) {
+
+	header := new([]string)
+	var keyIndexes []int
+	headerFunc := func(fields []string) error {
+		h := NewHeader(fields)
+		if err := h.Require(keyColumns...); err != nil {
+			return err
+		}
+		keyIndexes = make([]int, len(keyColumns))
+		for i, column := range keyColumns {
+			keyIndexes[i], _ = h.Index(column)
+		}
+		*header = slices.Clone(fields)
+		return nil
+	}
+	parseFunc := func(fields []string) (keyedRow, error) {
+		row := keyedRow{fields: fields, key: make([]string, len(keyIndexes))}
+		for i, index := range keyIndexes {
+			if index < len(fields) {
+				row.key[i] = fields[index]
+			}
+		}
+		return row, nil
+	}
+
+	records, err := iterator(input, true, DefaultBufferSize, headerFunc, parseFunc, opts)
+	return records, header, err
+}
+
+// DiffFiles returns an iterator over the rows which were added, removed or changed between oldInput and newInput.
+// Rows are matched by the values of keyColumns, which must be present in both headers. Columns present in both
+// headers are compared by name, other columns are ignored.
+//
+// With the HashJoin strategy oldInput is read into memory, changed and added rows are yielded in new input order and
+// removed rows follow in old input order. With the MergeJoin strategy both inputs must be sorted by keyColumns, such
+// as by SortFile with StringType keys, and rows are yielded in key order.
+//
+// Errors from either input are passed through. A key which repeats within an input is returned as a ParseError, and
+// the repeated row is not compared.
+func DiffFiles(oldInput, newInput io.Reader,
+	keyColumns []string,
+	strategy JoinStrategy,
+	opts ...ReaderOption) (iter.Seq2[RowDiff, error], error) {
+
+	if len(keyColumns) == 0 {
+		return nil, errors.New("DiffFiles: at least one key column is required")
+	}
+	oldRows, oldHeader, err := keyedRows(oldInput, keyColumns, opts)
+	if err != nil {
+		return nil, fmt.Errorf("DiffFiles: %w", err)
+	}
+	newRows, newHeader, err := keyedRows(newInput, keyColumns, opts)
+	if err != nil {
+		return nil, fmt.Errorf("DiffFiles: %w", err)
+	}
+
+	d := &differ{oldHeader: oldHeader, newHeader: newHeader}
+	switch strategy {
+	case HashJoin:
+		return d.hashDiff(oldRows, newRows), nil
+	case MergeJoin:
+		return d.mergeDiff(oldRows, newRows), nil
+	}
+	return nil, fmt.Errorf("DiffFiles: invalid join strategy %s", strategy)
+}
+
+// columnPair is the position of a column in the old and new headers.
+type columnPair struct {
+	name     string
+	oldIndex int
+	newIndex int
+}
+
+// differ compares rows from the old and new inputs.
+type differ struct {
+	oldHeader *[]string
+	newHeader *[]string
+	// columns are the columns present in both headers, available once both headers are read.
+	columns []columnPair
+}
+
+// compare returns a Changed RowDiff for rows with different values, or nil if the rows are equal.
+func (d *differ) compare(oldRow, newRow Record[keyedRow]) *RowDiff {
+	if d.columns == nil {
+		old := NewHeader(*d.oldHeader)
+		d.columns = []columnPair{}
+		for i, name := range *d.newHeader {
+			if oldIndex, ok := old.Index(name); ok {
+				d.columns = append(d.columns, columnPair{name: name, oldIndex: oldIndex, newIndex: i})
+			}
+		}
+	}
+
+	field := func(fields []string, i int) string {
+		if i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+	var changes []ColumnChange
+	for _, c := range d.columns {
+		oldValue, newValue := field(oldRow.Data.fields, c.oldIndex), field(newRow.Data.fields, c.newIndex)
+		if oldValue != newValue {
+			changes = append(changes, ColumnChange{Column: c.name, Old: oldValue, New: newValue})
+		}
+	}
+	if changes == nil {
+		return nil
+	}
+	return &RowDiff{
+		Change:  Changed,
+		Key:     newRow.Data.key,
+		OldLine: oldRow.LineNumber,
+		NewLine: newRow.LineNumber,
+		Old:     oldRow.Data.fields,
+		New:     newRow.Data.fields,
+		Changes: changes,
+	}
+}
+
+// added returns the RowDiff for a row only present in the new input.
+func added(row Record[keyedRow]) RowDiff {
+	return RowDiff{Change: Added, Key: row.Data.key, NewLine: row.LineNumber, New: row.Data.fields}
+}
+
+// removed returns the RowDiff for a row only present in the old input.
+func removed(row Record[keyedRow]) RowDiff {
+	return RowDiff{Change: Removed, Key: row.Data.key, OldLine: row.LineNumber, Old: row.Data.fields}
+}
+
+// duplicateKeyError returns a ParseError for a row whose key repeats within its input.
+func duplicateKeyError(row Record[keyedRow]) error {
+	pe := NewParseError(0, fmt.Errorf("duplicate key %q", row.Data.key))
+	pe.setPosition(recordPosition(row))
+	return pe
+}
+
+// hashDiff compares new rows with old rows read into memory.
+func (d *differ) hashDiff(oldRows, newRows iter.Seq2[Record[keyedRow], error]) iter.Seq2[RowDiff, error] {
+	return func(yield func(RowDiff, error) bool) {
+		oldByKey := make(map[string]Record[keyedRow])
+		var oldKeys []string
+		for row, err := range oldRows {
+			if err == nil {
+				key := row.Data.keyString()
+				if _, ok := oldByKey[key]; !ok {
+					oldByKey[key] = row
+					oldKeys = append(oldKeys, key)
+					continue
+				}
+				err = duplicateKeyError(row)
+			}
+			if !yield(RowDiff{}, err) || !isRecordError(err) {
+				return
+			}
+		}
+
+		matched := make(map[string]bool, len(oldByKey))
+		for row, err := range newRows {
+			if err == nil {
+				key := row.Data.keyString()
+				if matched[key] {
+					err = duplicateKeyError(row)
+				} else {
+					oldRow, ok := oldByKey[key]
+					matched[key] = true
+					switch {
+					case !ok:
+						if !yield(added(row), nil) {
+							return
+						}
+					default:
+						if diff := d.compare(oldRow, row); diff != nil && !yield(*diff, nil) {
+							return
+						}
+					}
+					continue
+				}
+			}
+			if !yield(RowDiff{}, err) || !isRecordError(err) {
+				return
+			}
+		}
+
+		for _, key := range oldKeys {
+			if !matched[key] && !yield(removed(oldByKey[key]), nil) {
+				return
+			}
+		}
+	}
+}
+
+// mergeDiff compares rows from old and new inputs sorted by key.
+func (d *differ) mergeDiff(oldRows, newRows iter.Seq2[Record[keyedRow], error]) iter.Seq2[RowDiff, error] {
+	return func(yield func(RowDiff, error) bool) {
+		oldNext, oldStop := iter.Pull2(oldRows)
+		defer oldStop()
+		newNext, newStop := iter.Pull2(newRows)
+		defer newStop()
+
+		keyString := func(row keyedRow) string {
+			return row.keyString()
+		}
+		o := &keyedCursor[keyedRow]{next: oldNext, keyFunc: keyString}
+		n := &keyedCursor[keyedRow]{next: newNext, keyFunc: keyString}
+
+		// advance advances a cursor, yielding a ParseError for each row with the same key as the previous row
+		advance := func(c *keyedCursor[keyedRow]) bool {
+			previous, started := c.key, c.started
+			for {
+				if !advanceCursor(c, yield) {
+					return false
+				}
+				if c.done || !started || c.key != previous {
+					return true
+				}
+				if !yield(RowDiff{}, duplicateKeyError(c.record)) {
+					return false
+				}
+			}
+		}
+
+		if !advance(o) || !advance(n) {
+			return
+		}
+		for !o.done || !n.done {
+			switch {
+			case n.done || (!o.done && o.key < n.key):
+				if !yield(removed(o.record), nil) || !advance(o) {
+					return
+				}
+			case o.done || n.key < o.key:
+				if !yield(added(n.record), nil) || !advance(n) {
+					return
+				}
+			default:
+				if diff := d.compare(o.record, n.record); diff != nil && !yield(*diff, nil) {
+					return
+				}
+				if !advance(o) || !advance(n) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// DiffReportHeader returns the header of a diff report for keyColumns.
+// Each report row contains the change type, the key column values, the old and new line numbers and, for changed
+// rows, the column with its old and new values.
+func DiffReportHeader(keyColumns []string) []string {
+	header := append([]string{"change"}, keyColumns...)
+	return append(header, "old_line", "new_line", "column", "old_value", "new_value")
+}
+
+// WriteDiffReport writes diffs to output as a CSV report with DiffReportHeader. Changed rows are written as a report
+// row for each changed column. The first error from diffs stops the report and is returned; use an ErrorPolicy to
+// skip errors from the inputs.
+func WriteDiffReport(output io.Writer,
+	keyColumns []string,
+	diffs iter.Seq2[RowDiff, error],
+	opts ...WriterOption) error {
+
+	writer, err := NewWriter(output, func(fields []string) ([]string, error) {
+		return fields, nil
+	}, opts...)
+	if err != nil {
+		return fmt.Errorf("WriteDiffReport: %w", err)
+	}
+	if err := writer.WriteHeader(DiffReportHeader(keyColumns)); err != nil {
+		return fmt.Errorf("WriteDiffReport: %w", err)
+	}
+
+	for diff, err := range diffs {
+		if err != nil {
+			return fmt.Errorf("WriteDiffReport: %w", err)
+		}
+		row := append([]string{diff.Change.String()}, diff.Key...)
+		row = append(row, lineString(diff.OldLine), lineString(diff.NewLine))
+		if diff.Change != Changed {
+			err = writer.Write(append(row, "", "", ""))
+		}
+		for _, c := range diff.Changes {
+			if err == nil {
+				err = writer.Write(append(slices.Clip(row), c.Column, c.Old, c.New))
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("WriteDiffReport: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("WriteDiffReport: %w", err)
+	}
+	return nil
+}
+
+// lineString formats a line number for a report, or "" for 0.
+func lineString(line int) string {
+	if line == 0 {
+		return ""
+	}
+	return strconv.Itoa(line)
+}
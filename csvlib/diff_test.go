@@ -0,0 +1,139 @@
+package csvlib
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	diffOld = "id,region,name,amount\n1,eu,ann,10\n2,eu,bob,20\n3,us,cal,30\n5,us,eve,50\n"
+	// diffNew adds a column, removes 3, changes 2 and 5 and adds 4
+	diffNew = "region,id,name,amount,note\neu,1,ann,10,x\neu,2,bob,25,\nus,4,dan,40,\nus,5,eva,55,\n"
+)
+
+func TestDiffFiles(t *testing.T) {
+	changed2 := RowDiff{
+		Change: Changed, Key: []string{"2"}, OldLine: 3, NewLine: 3,
+		Old:     []string{"2", "eu", "bob", "20"},
+		New:     []string{"eu", "2", "bob", "25", ""},
+		Changes: []ColumnChange{{Column: "amount", Old: "20", New: "25"}},
+	}
+	removed3 := RowDiff{Change: Removed, Key: []string{"3"}, OldLine: 4, Old: []string{"3", "us", "cal", "30"}}
+	added4 := RowDiff{Change: Added, Key: []string{"4"}, NewLine: 4, New: []string{"us", "4", "dan", "40", ""}}
+	changed5 := RowDiff{
+		Change: Changed, Key: []string{"5"}, OldLine: 5, NewLine: 5,
+		Old: []string{"5", "us", "eve", "50"},
+		New: []string{"us", "5", "eva", "55", ""},
+		Changes: []ColumnChange{
+			{Column: "name", Old: "eve", New: "eva"},
+			{Column: "amount", Old: "50", New: "55"},
+		},
+	}
+
+	tests := map[JoinStrategy][]RowDiff{
+		HashJoin:  {changed2, added4, changed5, removed3},
+		MergeJoin: {changed2, removed3, added4, changed5},
+	}
+	for strategy, want := range tests {
+		t.Run(strategy.String(), func(t *testing.T) {
+			diffs, err := DiffFiles(strings.NewReader(diffOld), strings.NewReader(diffNew), []string{"id"}, strategy)
+			if err != nil {
+				t.Fatalf("DiffFiles unexpected error %v", err)
+			}
+			var got []RowDiff
+			for d, err := range diffs {
+				if err != nil {
+					t.Fatalf("DiffFiles iterator error = %v", err)
+				}
+				got = append(got, d)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("DiffFiles found diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffFiles_Errors(t *testing.T) {
+	oldData := "id,name\n1,ann\n1,amy\n2,bob\n"
+	newData := "id,name\n1,ann\n2,bo\n2,bea\n"
+
+	for _, strategy := range []JoinStrategy{HashJoin, MergeJoin} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			diffs, err := DiffFiles(strings.NewReader(oldData), strings.NewReader(newData), []string{"id"}, strategy)
+			if err != nil {
+				t.Fatalf("DiffFiles unexpected error %v", err)
+			}
+			var changes []ChangeType
+			var errLines []int
+			for d, err := range diffs {
+				var pe *ParseError
+				switch {
+				case errors.As(err, &pe):
+					errLines = append(errLines, pe.LineNumber())
+				case err != nil:
+					t.Fatalf("DiffFiles iterator error = %v", err)
+				default:
+					changes = append(changes, d.Change)
+				}
+			}
+			if diff := cmp.Diff([]ChangeType{Changed}, changes); diff != "" {
+				t.Errorf("DiffFiles found diff (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff([]int{3, 4}, errLines); diff != "" {
+				t.Errorf("DiffFiles duplicate key lines diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// a missing key column ends the diff
+	diffs, err := DiffFiles(strings.NewReader(oldData), strings.NewReader("name\nann\n"), []string{"id"}, HashJoin)
+	if err != nil {
+		t.Fatalf("DiffFiles unexpected error %v", err)
+	}
+	var errs []error
+	for d, err := range diffs {
+		if err == nil {
+			t.Errorf("DiffFiles unexpected diff %+v", d)
+			continue
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 || !errors.Is(errs[len(errs)-1], ErrMissingColumn) {
+		t.Errorf("DiffFiles errors = %v, want ErrMissingColumn", errs)
+	}
+
+	if _, err := DiffFiles(strings.NewReader(oldData), strings.NewReader(newData), nil, HashJoin); err == nil {
+		t.Error("DiffFiles expected error for no key columns")
+	}
+	if _, err := DiffFiles(strings.NewReader(oldData), strings.NewReader(newData), []string{"id"},
+		JoinStrategy(5)); err == nil {
+		t.Error("DiffFiles expected error for invalid strategy")
+	}
+}
+
+func TestWriteDiffReport(t *testing.T) {
+	keys := []string{"region", "id"}
+	diffs, err := DiffFiles(strings.NewReader(diffOld), strings.NewReader(diffNew), keys, MergeJoin)
+	if err != nil {
+		t.Fatalf("DiffFiles unexpected error %v", err)
+	}
+
+	var output bytes.Buffer
+	if err := WriteDiffReport(&output, keys, diffs); err != nil {
+		t.Fatalf("WriteDiffReport unexpected error %v", err)
+	}
+	want := "change,region,id,old_line,new_line,column,old_value,new_value\n" +
+		"changed,eu,2,3,3,amount,20,25\n" +
+		"removed,us,3,4,,,,\n" +
+		"added,us,4,,4,,,\n" +
+		"changed,us,5,5,5,name,eve,eva\n" +
+		"changed,us,5,5,5,amount,50,55\n"
+	if diff := cmp.Diff(want, output.String()); diff != "" {
+		t.Errorf("WriteDiffReport found diff (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,207 @@
+package csvlib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Charset is the character encoding of CSV input without a byte order mark.
+type Charset int
+
+const (
+	// UTF8 input is read without transcoding.
+	UTF8 Charset = iota
+	// Latin1 is ISO-8859-1.
+	Latin1
+	// Windows1252 is the Windows Western European code page, a superset of the printable characters of Latin1.
+	Windows1252
+)
+
+// String returns the Charset name.
+func (c Charset) String() string {
+	switch c {
+	case UTF8:
+		return "UTF-8"
+	case Latin1:
+		return "ISO-8859-1"
+	case Windows1252:
+		return "Windows-1252"
+	}
+	return fmt.Sprintf("Charset(%d)", int(c))
+}
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// windows1252 maps the bytes 0x80 - 0x9f to their Windows-1252 code points. Unassigned bytes map to the Latin1 control
+// characters.
+var windows1252 = [32]rune{
+	0x20ac, 0x0081, 0x201a, 0x0192, 0x201e, 0x2026, 0x2020, 0x2021,
+	0x02c6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008d, 0x017d, 0x008f,
+	0x0090, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014,
+	0x02dc, 0x2122, 0x0161, 0x203a, 0x0153, 0x009d, 0x017e, 0x0178,
+}
+
+// WithCharset transcodes input in a single-byte charset to UTF-8.
+// A UTF-8 or UTF-16 byte order mark at the start of the input takes precedence over charset.
+func WithCharset(charset Charset) ReaderOption {
+	return option{reader: func(c *readerConfig) { c.charset = charset }}
+}
+
+// WithBOM writes a UTF-8 byte order mark before the first record, which identifies the output as UTF-8 to applications
+// such as Excel.
+func WithBOM() WriterOption {
+	return option{writer: func(c *writerConfig) { c.bom = true }}
+}
+
+// decode returns a reader which strips a byte order mark from input and transcodes UTF-16 input, identified by its
+// byte order mark, or input in a single-byte charset to UTF-8.
+// bomLength is the length of a stripped UTF-8 byte order mark. transcoded is true if the input is transcoded.
+func decode(input *bufio.Reader, charset Charset) (reader io.Reader, bomLength int64, transcoded bool) {
+	bom, _ := input.Peek(len(utf8BOM))
+	switch {
+	case bytes.HasPrefix(bom, utf8BOM):
+		input.Discard(len(utf8BOM))
+		return input, int64(len(utf8BOM)), false
+	case bytes.HasPrefix(bom, utf16LEBOM):
+		input.Discard(len(utf16LEBOM))
+		return &utf16Reader{input: input, order: binary.LittleEndian}, 0, true
+	case bytes.HasPrefix(bom, utf16BEBOM):
+		input.Discard(len(utf16BEBOM))
+		return &utf16Reader{input: input, order: binary.BigEndian}, 0, true
+	case charset == Latin1 || charset == Windows1252:
+		return &singleByteReader{input: input, charset: charset}, 0, true
+	}
+	return input, 0, false
+}
+
+// decodeBatchSize is the number of UTF-8 bytes decoded by a transcoding reader before they are returned.
+const decodeBatchSize = 4096
+
+// decodeBuffer holds UTF-8 data decoded by a transcoding reader which has not yet been read.
+type decodeBuffer struct {
+	buf     []byte
+	pending []byte
+	// err is the error which ended decoding.
+	err error
+}
+
+// read copies pending data to p, calling fill to decode data once pending data is exhausted.
+func (b *decodeBuffer) read(p []byte, fill func()) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if len(b.pending) == 0 && b.err == nil {
+		b.pending = b.buf[:0]
+		fill()
+		b.buf = b.pending[:0]
+	}
+	if len(b.pending) > 0 {
+		n := copy(p, b.pending)
+		b.pending = b.pending[n:]
+		return n, nil
+	}
+	return 0, b.err
+}
+
+// utf16Reader transcodes UTF-16 input to UTF-8. Invalid surrogates and a trailing odd byte are decoded as
+// utf8.RuneError.
+type utf16Reader struct {
+	decodeBuffer
+	input *bufio.Reader
+	order binary.ByteOrder
+	// next is a code unit read ahead of a missing trailing surrogate, valid if hasNext is true.
+	next    uint16
+	hasNext bool
+}
+
+// Read implements io.Reader.
+func (r *utf16Reader) Read(p []byte) (int, error) {
+	return r.read(p, r.fill)
+}
+
+// fill decodes a batch of code units.
+func (r *utf16Reader) fill() {
+	for len(r.pending) < decodeBatchSize && r.err == nil {
+		unit, err := r.readUnit()
+		if err != nil {
+			r.err = err
+			break
+		}
+		if !utf16.IsSurrogate(rune(unit)) {
+			r.pending = utf8.AppendRune(r.pending, rune(unit))
+			continue
+		}
+
+		decoded := utf8.RuneError
+		if unit < 0xdc00 {
+			trail, err := r.readUnit()
+			switch {
+			case err != nil:
+				r.err = err
+			case trail >= 0xdc00 && trail <= 0xdfff:
+				decoded = utf16.DecodeRune(rune(unit), rune(trail))
+			default:
+				r.next, r.hasNext = trail, true
+			}
+		}
+		r.pending = utf8.AppendRune(r.pending, decoded)
+	}
+}
+
+// readUnit returns the next code unit from the input.
+func (r *utf16Reader) readUnit() (uint16, error) {
+	if r.hasNext {
+		r.hasNext = false
+		return r.next, nil
+	}
+	var unit [2]byte
+	_, err := io.ReadFull(r.input, unit[:])
+	if err == io.ErrUnexpectedEOF {
+		r.pending = utf8.AppendRune(r.pending, utf8.RuneError)
+		err = io.EOF
+	}
+	if err != nil {
+		return 0, err
+	}
+	return r.order.Uint16(unit[:]), nil
+}
+
+// singleByteReader transcodes input in a single-byte charset to UTF-8.
+type singleByteReader struct {
+	decodeBuffer
+	input   *bufio.Reader
+	charset Charset
+}
+
+// Read implements io.Reader.
+func (r *singleByteReader) Read(p []byte) (int, error) {
+	return r.read(p, r.fill)
+}
+
+// fill decodes a batch of bytes.
+func (r *singleByteReader) fill() {
+	for len(r.pending) < decodeBatchSize {
+		b, err := r.input.ReadByte()
+		if err != nil {
+			r.err = err
+			return
+		}
+		switch {
+		case b < utf8.RuneSelf:
+			r.pending = append(r.pending, b)
+		case r.charset == Windows1252 && b < 0xa0:
+			r.pending = utf8.AppendRune(r.pending, windows1252[b-0x80])
+		default:
+			r.pending = utf8.AppendRune(r.pending, rune(b))
+		}
+	}
+}
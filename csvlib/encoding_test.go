@@ -0,0 +1,191 @@
+package csvlib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func utf16Data(data string, order binary.AppendByteOrder, bom []byte) []byte {
+	encoded := bytes.Clone(bom)
+	for _, unit := range utf16.Encode([]rune(data)) {
+		encoded = order.AppendUint16(encoded, unit)
+	}
+	return encoded
+}
+
+func TestIterator_Encodings(t *testing.T) {
+	csvData := "name,city\nJosé,Zürich\n😀,Köln\n"
+	want := [][]string{{"José", "Zürich"}, {"😀", "Köln"}}
+
+	tests := map[string]struct {
+		input []byte
+		opts  []ReaderOption
+		want  [][]string
+	}{
+		"utf-8": {
+			input: []byte(csvData),
+			want:  want,
+		},
+		"utf-8 bom": {
+			input: append(bytes.Clone(utf8BOM), csvData...),
+			want:  want,
+		},
+		"utf-8 bom takes precedence over charset": {
+			input: append(bytes.Clone(utf8BOM), csvData...),
+			opts:  []ReaderOption{WithCharset(Latin1)},
+			want:  want,
+		},
+		"utf-16le bom": {
+			input: utf16Data(csvData, binary.LittleEndian, utf16LEBOM),
+			want:  want,
+		},
+		"utf-16be bom": {
+			input: utf16Data(csvData, binary.BigEndian, utf16BEBOM),
+			want:  want,
+		},
+		"latin-1": {
+			input: []byte("name,city\nJos\xe9,Z\xfcrich\n\x80,K\xf6ln\n"),
+			opts:  []ReaderOption{WithCharset(Latin1)},
+			want:  [][]string{{"José", "Zürich"}, {"\u0080", "Köln"}},
+		},
+		"windows-1252": {
+			input: []byte("name,city\nJos\xe9,Z\xfcrich\n\x80\x96\x81,K\xf6ln\n"),
+			opts:  []ReaderOption{WithCharset(Windows1252)},
+			want:  [][]string{{"José", "Zürich"}, {"€–\u0081", "Köln"}},
+		},
+		"gzip utf-16le": {
+			input: gzipData(t, string(utf16Data(csvData, binary.LittleEndian, utf16LEBOM))),
+			want:  want,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var header []string
+			iter, err := NewHeaderIterator(bytes.NewReader(tc.input), nil, func(row Row) ([]string, error) {
+				header = row.Header().Names()
+				return row.Fields(), nil
+			}, tc.opts...)
+			if err != nil {
+				t.Fatalf("NewHeaderIterator unexpected error %v", err)
+			}
+			var got [][]string
+			for rec, err := range iter {
+				if err != nil {
+					t.Fatalf("iterator error = %v", err)
+				}
+				got = append(got, rec.Data)
+			}
+			if diff := cmp.Diff([]string{"name", "city"}, header); diff != "" {
+				t.Errorf("header found diff (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("iterator found diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIterator_InvalidUTF16(t *testing.T) {
+	// an unpaired leading surrogate, an unpaired trailing surrogate and an odd trailing byte
+	input := utf16Data("a", binary.LittleEndian, utf16LEBOM)
+	input = binary.LittleEndian.AppendUint16(input, 0xd800)
+	input = binary.LittleEndian.AppendUint16(input, 'b')
+	input = binary.LittleEndian.AppendUint16(input, 0xdc00)
+	input = append(input, 'c')
+
+	iter, err := NewDefaultIterator(bytes.NewReader(input), false, func(fields []string) (string, error) {
+		return fields[0], nil
+	})
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	var got []string
+	for rec, err := range iter {
+		if err != nil {
+			t.Fatalf("iterator error = %v", err)
+		}
+		got = append(got, rec.Data)
+	}
+	if diff := cmp.Diff([]string{"a�b��"}, got); diff != "" {
+		t.Errorf("iterator found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestIterator_BOMOffsets(t *testing.T) {
+	csvData := string(utf8BOM) + sampleCsv(t, true)
+	var saved Checkpoint
+	iter, err := NewDefaultIterator(strings.NewReader(csvData), true, customRecordParseFunc, WithCheckpoints(1,
+		func(cp Checkpoint) {
+			if saved.Offset == 0 {
+				saved = cp
+			}
+		}))
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	var offsets []int64
+	for rec, err := range iter {
+		if err != nil {
+			t.Fatalf("iterator error = %v", err)
+		}
+		offsets = append(offsets, rec.Offset)
+	}
+	if want := int64(strings.Index(csvData, `"John"`)); offsets[0] != want {
+		t.Errorf("offset = %d, want %d", offsets[0], want)
+	}
+
+	// checkpoint offsets account for the byte order mark
+	resumed, err := NewIteratorFromCheckpoint(strings.NewReader(csvData), true, saved, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewIteratorFromCheckpoint unexpected error %v", err)
+	}
+	for rec, err := range resumed {
+		if err != nil || rec.Offset != offsets[1] {
+			t.Errorf("resumed record = %+v, %v, want offset %d", rec, err, offsets[1])
+		}
+	}
+
+	// transcoded input cannot be resumed
+	utf16Input := utf16Data(sampleCsv(t, true), binary.LittleEndian, utf16LEBOM)
+	resumed, err = NewIteratorFromCheckpoint(bytes.NewReader(utf16Input), true, saved, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewIteratorFromCheckpoint unexpected error %v", err)
+	}
+	for _, err := range resumed {
+		var ie *IterationError
+		if !errors.As(err, &ie) || !strings.Contains(err.Error(), "transcoded input") {
+			t.Errorf("iterator error = %v, want transcoded input IterationError", err)
+		}
+	}
+}
+
+func TestWithCharset_Invalid(t *testing.T) {
+	_, err := NewDefaultIterator(strings.NewReader(""), true, customRecordParseFunc, WithCharset(Charset(9)))
+	if err == nil || !strings.Contains(err.Error(), "invalid charset Charset(9)") {
+		t.Errorf("NewDefaultIterator error = %v, want invalid charset", err)
+	}
+}
+
+func TestWriter_BOM(t *testing.T) {
+	var output bytes.Buffer
+	writer, err := NewWriter(&output, customRecordConvertFunc, WithBOM())
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+	if err := writer.Write(CustomRecord{FirstName: "José", LastName: "Doe"}); err != nil {
+		t.Fatalf("Write unexpected error %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+	if want := "\ufeffJosé,Doe\n"; output.String() != want {
+		t.Errorf("output = %q, want %q", output.String(), want)
+	}
+}
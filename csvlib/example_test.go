@@ -43,6 +43,43 @@ func ExampleNewDefaultIterator() {
 	// record: 3, first name: "Jane", last name: "Doe"
 }
 
+func ExampleNewHeaderIterator() {
+	// columns may appear in any order
+	const csvData = `"last_name","first_name"
+"Doe","John"
+"Doe","Jane"
+`
+	input := strings.NewReader(csvData)
+
+	parseFunc := func(row Row) (ExampleRecord, error) {
+		firstName, err := row.Get("first_name")
+		if err != nil {
+			return ExampleRecord{}, err
+		}
+		lastName, err := row.Get("last_name")
+		if err != nil {
+			return ExampleRecord{}, err
+		}
+		return ExampleRecord{FirstName: firstName, LastName: lastName}, nil
+	}
+
+	iter, err := NewHeaderIterator(input, []string{"first_name", "last_name"}, parseFunc)
+	if err != nil {
+		fmt.Println("error: ", err.Error())
+	}
+
+	for rec, err := range iter {
+		if err != nil {
+			fmt.Println("error: ", err.Error())
+			break
+		}
+		fmt.Printf("record: %d, first name: %q, last name: %q\n", rec.LineNumber, rec.Data.FirstName, rec.Data.LastName)
+	}
+	// Output:
+	// record: 2, first name: "John", last name: "Doe"
+	// record: 3, first name: "Jane", last name: "Doe"
+}
+
 // exampleRecordToCsv converts ExampleRecord to a []string/csv record.
 func exampleRecordToCsv(rec ExampleRecord) ([]string, error) {
 	return []string{rec.FirstName, rec.LastName}, nil
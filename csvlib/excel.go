@@ -0,0 +1,121 @@
+package csvlib
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FormulaEscaping determines how a Writer neutralizes cells which a spreadsheet application may interpret as a
+// formula.
+type FormulaEscaping int
+
+const (
+	// EscapeWithPrefix prefixes formula cells with a single quote, which spreadsheet applications display as text.
+	EscapeWithPrefix FormulaEscaping = iota
+	// EscapeWithQuotes prefixes formula cells with a single quote and quotes the field, following the OWASP guidance
+	// for CSV injection.
+	EscapeWithQuotes
+)
+
+// String returns the FormulaEscaping name.
+func (e FormulaEscaping) String() string {
+	switch e {
+	case EscapeWithPrefix:
+		return "prefix"
+	case EscapeWithQuotes:
+		return "quotes"
+	}
+	return "FormulaEscaping(" + strconv.Itoa(int(e)) + ")"
+}
+
+// formulaPrefix is prepended to formula cells by WithFormulaEscaping.
+const formulaPrefix = "'"
+
+// WithFormulaEscaping neutralizes written cells which begin with '=', '+', '-', '@', tab or carriage return, and which
+// a spreadsheet application may therefore evaluate as a formula. Plain decimal numbers, such as -1.5, are written
+// unchanged.
+// Escaping applies to the header and to every record, and alters the written values: consumers other than
+// spreadsheets will read the prefixed value.
+func WithFormulaEscaping(escaping FormulaEscaping) WriterOption {
+	return option{writer: func(c *writerConfig) {
+		c.formulaEscaping = &escaping
+	}}
+}
+
+// WithFormulaDetection returns records containing a cell which may be evaluated as a formula by a spreadsheet
+// application as a ValidationError, with a Violation for each such cell. Cells are detected using the rules of
+// WithFormulaEscaping. Violations are named by header column, or by 1-based field position if the input does not have
+// a header. Detection is combined with WithSchema validation, if configured.
+func WithFormulaDetection() ReaderOption {
+	return option{reader: func(c *readerConfig) { c.detectFormulas = true }}
+}
+
+// decimalNumber matches plain signed decimal numbers, which are exempt from formula detection.
+var decimalNumber = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// isFormula reports whether a spreadsheet application may evaluate value as a formula.
+func isFormula(value string) bool {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return false
+	}
+	return !decimalNumber.MatchString(value)
+}
+
+// formulaViolations returns a Violation for each field which may be evaluated as a formula.
+// header names the fields, if not nil.
+func formulaViolations(fields []string, header []string) []Violation {
+	var violations []Violation
+	for i, field := range fields {
+		if !isFormula(field) {
+			continue
+		}
+		column := strconv.Itoa(i + 1)
+		if i < len(header) {
+			column = header[i]
+		}
+		violations = append(violations, Violation{
+			Column: column,
+			Value:  field,
+			Reason: "value may be evaluated as a formula",
+		})
+	}
+	return violations
+}
+
+// escapeFormulas returns csvFields with formula cells prefixed, and whether any field was escaped.
+// csvFields is not modified.
+func escapeFormulas(csvFields []string) ([]string, bool) {
+	var escaped []string
+	for i, field := range csvFields {
+		if !isFormula(field) {
+			continue
+		}
+		if escaped == nil {
+			escaped = append([]string(nil), csvFields...)
+		}
+		escaped[i] = formulaPrefix + field
+	}
+	if escaped == nil {
+		return csvFields, false
+	}
+	return escaped, true
+}
+
+// fieldNeedsQuotes reports whether field must be quoted when written with delimiter comma.
+// The rules mirror those applied by encoding/csv.
+func fieldNeedsQuotes(field string, comma rune) bool {
+	if field == "" {
+		return false
+	}
+	if field == `\.` {
+		return true
+	}
+	if strings.ContainsRune(field, comma) || strings.ContainsAny(field, "\"\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(field)
+	return unicode.IsSpace(r)
+}
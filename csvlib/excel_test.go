@@ -0,0 +1,169 @@
+package csvlib
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithFormulaEscaping(t *testing.T) {
+	records := [][]string{
+		{"name", "amount", "note"},
+		{"=SUM(A1:A2)", "-1.5", "+cmd|' /C calc'!A0"},
+		{"@ann", "-", "\tx"},
+		{"ann, a", "+3", "safe"},
+		{"-inf", "+Infinity", "-0x1p3"},
+	}
+
+	tests := []struct {
+		name string
+		opts []WriterOption
+		want string
+	}{
+		{
+			name: "prefix",
+			opts: []WriterOption{WithFormulaEscaping(EscapeWithPrefix)},
+			want: "name,amount,note\n" +
+				"'=SUM(A1:A2),-1.5,'+cmd|' /C calc'!A0\n" +
+				"'@ann,'-,'\tx\n" +
+				"\"ann, a\",+3,safe\n" +
+				"'-inf,'+Infinity,'-0x1p3\n",
+		},
+		{
+			name: "quotes",
+			opts: []WriterOption{WithFormulaEscaping(EscapeWithQuotes)},
+			want: "name,amount,note\n" +
+				"\"'=SUM(A1:A2)\",-1.5,\"'+cmd|' /C calc'!A0\"\n" +
+				"\"'@ann\",\"'-\",\"'\tx\"\n" +
+				"\"ann, a\",+3,safe\n" +
+				"\"'-inf\",\"'+Infinity\",\"'-0x1p3\"\n",
+		},
+		{
+			name: "quote all",
+			opts: []WriterOption{WithFormulaEscaping(EscapeWithPrefix), WithQuoteAll()},
+			want: "\"name\",\"amount\",\"note\"\n" +
+				"\"'=SUM(A1:A2)\",\"-1.5\",\"'+cmd|' /C calc'!A0\"\n" +
+				"\"'@ann\",\"'-\",\"'\tx\"\n" +
+				"\"ann, a\",\"+3\",\"safe\"\n" +
+				"\"'-inf\",\"'+Infinity\",\"'-0x1p3\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			writer, err := NewWriter(&output, func(fields []string) ([]string, error) { return fields, nil },
+				tt.opts...)
+			if err != nil {
+				t.Fatalf("NewWriter unexpected error %v", err)
+			}
+			for _, rec := range records {
+				if err := writer.Write(rec); err != nil {
+					t.Fatalf("Write unexpected error %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close unexpected error %v", err)
+			}
+			if diff := cmp.Diff(tt.want, output.String()); diff != "" {
+				t.Errorf("output found diff (-want +got):\n%s", diff)
+			}
+			if got := records[1][0]; got != "=SUM(A1:A2)" {
+				t.Errorf("Write modified the record to %q", got)
+			}
+		})
+	}
+
+	if _, err := NewWriter(&bytes.Buffer{}, customRecordConvertFunc, WithFormulaEscaping(FormulaEscaping(9))); err == nil {
+		t.Error("NewWriter expected an error for an invalid FormulaEscaping")
+	}
+}
+
+func TestIsFormula(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "", want: false},
+		{value: "ann", want: false},
+		{value: "-1.5", want: false},
+		{value: "+3", want: false},
+		{value: "-2.5e-3", want: false},
+		{value: "=1", want: true},
+		{value: "-", want: true},
+		{value: "-inf", want: true},
+		{value: "+Infinity", want: true},
+		{value: "-NaN", want: true},
+		{value: "-0x1p3", want: true},
+		{value: "+1_000", want: true},
+		{value: "-1.", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := isFormula(tt.value); got != tt.want {
+				t.Errorf("isFormula(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithFormulaDetection(t *testing.T) {
+	csvData := "name,amount\nann,-1.5\n=HYPERLINK(1),@SUM(1)\nbob,\n-inf,2e3\n"
+	schema := &Schema{Columns: []Column{{Name: "amount", Type: FloatType}}}
+
+	iterator, err := NewDefaultIterator(strings.NewReader(csvData), true,
+		func(fields []string) ([]string, error) { return fields, nil },
+		WithFormulaDetection(), WithSchema(schema))
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+
+	var got [][]Violation
+	records := 0
+	for _, err := range iterator {
+		var ve *ValidationError
+		if errors.As(err, &ve) {
+			got = append(got, ve.Violations)
+		} else if err == nil {
+			records++
+		}
+	}
+
+	want := [][]Violation{
+		{
+			{Column: "amount", Value: "@SUM(1)", Reason: `value "@SUM(1)" is not a valid float`},
+			{Column: "name", Value: "=HYPERLINK(1)", Reason: "value may be evaluated as a formula"},
+			{Column: "amount", Value: "@SUM(1)", Reason: "value may be evaluated as a formula"},
+		},
+		{
+			{Column: "amount", Reason: "value is required"},
+		},
+		{
+			{Column: "name", Value: "-inf", Reason: "value may be evaluated as a formula"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("violations found diff (-want +got):\n%s", diff)
+	}
+	if records != 1 {
+		t.Errorf("valid records = %d, want 1", records)
+	}
+}
+
+func TestWithFormulaDetection_NoHeader(t *testing.T) {
+	iterator, err := NewDefaultIterator(strings.NewReader("a,+b\n"), false,
+		func(fields []string) ([]string, error) { return fields, nil }, WithFormulaDetection())
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	for _, err := range iterator {
+		var ve *ValidationError
+		if !errors.As(err, &ve) || ve.Violations[0].Column != "2" {
+			t.Errorf("error = %v, want a ValidationError for column 2", err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package csvlib
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// defaultFileMode is the permission mode of files created by NewFileWriter when the target path does not exist.
+const defaultFileMode fs.FileMode = 0o644
+
+// NewFileIterator returns an iterator over the CSV records in the file at path.
+// The file is opened when iteration begins and closed when iteration ends, including when the consumer breaks out of
+// the loop early. Each range over the iterator reads the file from the start, or from the checkpoint if created
+// WithResume. An error opening or closing the file is returned as an IterationError.
+func NewFileIterator[T any](path string,
+	hasHeader bool,
+	conversionFunc ParseFunc[T],
+	opts ...ReaderOption) (iter.Seq2[Record[T], error], error) {
+
+	if conversionFunc == nil {
+		err := errors.New("NewFileIterator: conversionFunc is required")
+		return nil, NewIterationError(0, err)
+	}
+	if _, err := newReaderConfig(DefaultBufferSize, opts); err != nil {
+		return nil, NewIterationError(0, fmt.Errorf("NewFileIterator: %w", err))
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, NewIterationError(0, fmt.Errorf("NewFileIterator: %w", err))
+	}
+
+	return func(yield func(Record[T], error) bool) {
+		file, err := os.Open(path)
+		if err != nil {
+			yield(Record[T]{}, NewIterationError(0, fmt.Errorf("NewFileIterator: %w", err)))
+			return
+		}
+
+		// the file is closed by the deferred call if the consumer breaks out of the loop or panics
+		closed := false
+		defer func() {
+			if !closed {
+				file.Close()
+			}
+		}()
+
+		records, err := NewDefaultIterator(file, hasHeader, conversionFunc, opts...)
+		if err != nil {
+			yield(Record[T]{}, err)
+			return
+		}
+		for record, err := range records {
+			if !yield(record, err) {
+				return
+			}
+		}
+
+		closed = true
+		if err := file.Close(); err != nil {
+			yield(Record[T]{}, NewIterationError(0, fmt.Errorf("NewFileIterator: close error %w", err)))
+		}
+	}, nil
+}
+
+// NewFileWriter creates a new Writer which writes records to the file at path.
+// Records are written to a temporary file in the same directory, which replaces path when the Writer is closed. path
+// is left unchanged if the Writer is aborted, the data cannot be written, or the process exits before Close.
+// Close may return an error after path has been replaced if the directory cannot be synced, in which case the
+// replacement may not survive a system crash. An existing file's permission mode is preserved.
+func NewFileWriter[T any](path string, convertFunc ConvertFunc[T], opts ...WriterOption) (Writer[T], error) {
+	file, err := newAtomicFile(path)
+	if err != nil {
+		return Writer[T]{}, fmt.Errorf("NewFileWriter: %w", err)
+	}
+
+	w, err := NewWriter(file.temp, convertFunc, opts...)
+	if err != nil {
+		file.abort()
+		return Writer[T]{}, err
+	}
+	w.file = file
+	return w, nil
+}
+
+// NewFileStructWriter creates a new Writer which writes records of type T to the file at path using its csv struct
+// tags. See NewFileWriter for the file lifecycle and NewStructConvertFunc for column selection.
+func NewFileStructWriter[T any](path string, columns []string, opts ...WriterOption) (Writer[T], error) {
+	convertFunc, header, err := NewStructConvertFunc[T](columns)
+	if err != nil {
+		return Writer[T]{}, fmt.Errorf("NewFileStructWriter: %w", err)
+	}
+
+	w, err := NewFileWriter(path, convertFunc, opts...)
+	if err != nil {
+		return Writer[T]{}, err
+	}
+	w.header = header
+	return w, nil
+}
+
+// atomicFile is a temporary file which atomically replaces its target path when committed.
+type atomicFile struct {
+	path string
+	mode fs.FileMode
+	temp *os.File
+	// done is true once the file is committed or aborted.
+	done bool
+}
+
+// newAtomicFile creates a temporary file in the directory of path.
+func newAtomicFile(path string) (*atomicFile, error) {
+	mode := defaultFileMode
+	if info, err := os.Stat(path); err == nil {
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%q is not a regular file", path)
+		}
+		mode = info.Mode().Perm()
+	}
+
+	dir, base := filepath.Split(path)
+	temp, err := os.CreateTemp(dir, "."+base+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temporary file %w", err)
+	}
+	return &atomicFile{path: path, mode: mode, temp: temp}, nil
+}
+
+// commit syncs and closes the temporary file and renames it to the target path.
+// The temporary file is removed if it cannot be committed.
+func (f *atomicFile) commit() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+
+	err := f.temp.Chmod(f.mode)
+	if err == nil {
+		err = f.temp.Sync()
+	}
+	if closeErr := f.temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.temp.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(f.temp.Name())
+		return fmt.Errorf("error replacing %q %w", f.path, err)
+	}
+	// the rename is only durable once the directory entry is written, directories cannot be synced on Windows
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	if err := syncDir(filepath.Dir(f.path)); err != nil {
+		return fmt.Errorf("error syncing directory of %q %w", f.path, err)
+	}
+	return nil
+}
+
+// syncDir commits the directory's entries to stable storage.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = dir.Sync()
+	if closeErr := dir.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// abort closes and removes the temporary file.
+func (f *atomicFile) abort() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+
+	f.temp.Close()
+	if err := os.Remove(f.temp.Name()); err != nil {
+		return fmt.Errorf("error removing temporary file %w", err)
+	}
+	return nil
+}
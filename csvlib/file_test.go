@@ -0,0 +1,210 @@
+package csvlib
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileIterator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sample.csv")
+	if err := os.WriteFile(path, []byte(sampleCsv(t, true)), 0o644); err != nil {
+		t.Fatalf("WriteFile unexpected error %v", err)
+	}
+
+	iter, err := NewFileIterator(path, true, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewFileIterator unexpected error %v", err)
+	}
+
+	// each range reads the file from the start, including after breaking out of the loop
+	for range 2 {
+		var got []CustomRecord
+		for rec, err := range iter {
+			if err != nil {
+				t.Fatalf("iterator error = %v", err)
+			}
+			got = append(got, rec.Data)
+			break
+		}
+		want := []CustomRecord{{FirstName: "John", LastName: "Doe"}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("iterator found diff (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestFileIterator_Panic(t *testing.T) {
+	if _, err := os.Stat("/proc/self/fd"); err != nil {
+		t.Skip("open file descriptors cannot be counted")
+	}
+	openFiles := func() int {
+		entries, _ := os.ReadDir("/proc/self/fd")
+		return len(entries)
+	}
+
+	path := filepath.Join(t.TempDir(), "sample.csv")
+	if err := os.WriteFile(path, []byte(sampleCsv(t, true)), 0o644); err != nil {
+		t.Fatalf("WriteFile unexpected error %v", err)
+	}
+	iter, err := NewFileIterator(path, true, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewFileIterator unexpected error %v", err)
+	}
+
+	before := openFiles()
+	func() {
+		defer func() { recover() }()
+		for range iter {
+			panic("consumer panic")
+		}
+	}()
+	if after := openFiles(); after != before {
+		t.Errorf("open files = %d after a panic, want %d", after, before)
+	}
+}
+
+func TestFileIterator_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewFileIterator(filepath.Join(dir, "missing.csv"), true, customRecordParseFunc); err == nil {
+		t.Error("NewFileIterator expected error for missing file")
+	}
+	if _, err := NewFileIterator[CustomRecord](filepath.Join(dir, "missing.csv"), true, nil); err == nil {
+		t.Error("NewFileIterator expected error for nil conversionFunc")
+	}
+
+	// the file is removed after the iterator is created
+	path := filepath.Join(dir, "removed.csv")
+	if err := os.WriteFile(path, []byte(sampleCsv(t, true)), 0o644); err != nil {
+		t.Fatalf("WriteFile unexpected error %v", err)
+	}
+	iter, err := NewFileIterator(path, true, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewFileIterator unexpected error %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Remove unexpected error %v", err)
+	}
+	for _, err := range iter {
+		var ie *IterationError
+		if !errors.As(err, &ie) || !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("iterator error = %v, want IterationError wrapping os.ErrNotExist", err)
+		}
+	}
+}
+
+func TestFileWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "output.csv")
+	if err := os.WriteFile(path, []byte("previous\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile unexpected error %v", err)
+	}
+
+	writer, err := NewFileWriter(path, customRecordConvertFunc)
+	if err != nil {
+		t.Fatalf("NewFileWriter unexpected error %v", err)
+	}
+	if err := writer.Write(CustomRecord{FirstName: "John", LastName: "Doe"}); err != nil {
+		t.Fatalf("Write unexpected error %v", err)
+	}
+	writer.Flush()
+
+	// the target is unchanged until the writer is closed
+	assertFileContents(t, path, "previous\n")
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+	assertFileContents(t, path, "John,Doe\n")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat unexpected error %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want %v", info.Mode().Perm(), os.FileMode(0o600))
+	}
+	assertDirEntries(t, dir, 1)
+}
+
+func TestFileWriter_Abort(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "output.csv")
+
+	writer, err := NewFileWriter(path, customRecordConvertFunc)
+	if err != nil {
+		t.Fatalf("NewFileWriter unexpected error %v", err)
+	}
+	if err := writer.Write(CustomRecord{FirstName: "John", LastName: "Doe"}); err != nil {
+		t.Fatalf("Write unexpected error %v", err)
+	}
+	if err := writer.Abort(); err != nil {
+		t.Fatalf("Abort unexpected error %v", err)
+	}
+	// Close after Abort does not create the target
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Stat error = %v, want os.ErrNotExist", err)
+	}
+	assertDirEntries(t, dir, 0)
+}
+
+func TestFileStructWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.csv")
+	writer, err := NewFileStructWriter[TaggedRecord](path, []string{"name"})
+	if err != nil {
+		t.Fatalf("NewFileStructWriter unexpected error %v", err)
+	}
+	if err := writer.WriteHeader(writer.Header()); err != nil {
+		t.Fatalf("WriteHeader unexpected error %v", err)
+	}
+	if err := writer.Write(TaggedRecord{Name: "John"}); err != nil {
+		t.Fatalf("Write unexpected error %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+	assertFileContents(t, path, "name\nJohn\n")
+}
+
+func TestFileWriter_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewFileWriter(filepath.Join(dir, "missing", "output.csv"), customRecordConvertFunc); err == nil {
+		t.Error("NewFileWriter expected error for missing directory")
+	}
+	if _, err := NewFileWriter(dir, customRecordConvertFunc); err == nil {
+		t.Error("NewFileWriter expected error for directory path")
+	}
+	if _, err := NewFileWriter[CustomRecord](filepath.Join(dir, "output.csv"), nil); err == nil {
+		t.Error("NewFileWriter expected error for nil convertFunc")
+	}
+	assertDirEntries(t, dir, 0)
+}
+
+func assertFileContents(t *testing.T, path string, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile unexpected error %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("file contents = %q, want %q", got, want)
+	}
+}
+
+func assertDirEntries(t *testing.T, dir string, want int) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir unexpected error %v", err)
+	}
+	if len(entries) != want {
+		t.Errorf("directory has %d entries, want %d: %v", len(entries), want, entries)
+	}
+}
@@ -0,0 +1,231 @@
+package csvlib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Alignment is the position of a value within a fixed-width column.
+type Alignment int
+
+const (
+	// AlignLeft values are followed by padding.
+	AlignLeft Alignment = iota
+	// AlignRight values are preceded by padding.
+	AlignRight
+)
+
+// String returns the Alignment name.
+func (a Alignment) String() string {
+	switch a {
+	case AlignLeft:
+		return "left"
+	case AlignRight:
+		return "right"
+	}
+	return fmt.Sprintf("Alignment(%d)", int(a))
+}
+
+// FixedColumn is a column of a fixed-width record. Offsets and widths are measured in characters.
+type FixedColumn struct {
+	Name string
+	// Offset is the 0-based position of the column within the record.
+	Offset int
+	Width  int
+	// Align determines the side of the value which is padded.
+	Align Alignment
+	// Pad is the padding character. Space is used if Pad is 0.
+	Pad rune
+	// KeepPad reads a value consisting only of padding as a single padding character rather than an empty value, so
+	// that a zero padded zero, written as "000", is read as "0". Empty values are then read as a padding character.
+	KeepPad bool
+}
+
+// FixedLayout declares the columns of a fixed-width file.
+// Columns are returned and written in the order they are declared, and may not overlap. Characters outside of the
+// columns are ignored when reading and written as spaces.
+type FixedLayout struct {
+	Columns []FixedColumn
+	// Length, if > 0, is the required record length. Lines of another length are returned as an IterationError, and
+	// written records are padded to Length. Otherwise lines shorter than the layout are read as if padded.
+	Length int
+}
+
+// WithFixedWidth reads and writes fixed-width records with layout rather than delimited records.
+// Delimiter, quoting and field count options do not apply to fixed-width records. Empty lines and lines starting with
+// the WithComment character are skipped. Padding is removed from values when reading, so a header line yields the
+// column names.
+func WithFixedWidth(layout *FixedLayout) Option {
+	return option{
+		reader: func(c *readerConfig) { c.layout = layout },
+		writer: func(c *writerConfig) { c.layout = layout },
+	}
+}
+
+// NewFixedWidthIterator returns an iterator over the fixed-width records in input, read with layout.
+// Records are returned with the same Record, ParseFunc, IterationError and ParseError semantics as NewDefaultIterator.
+// If hasHeader is true the first line is a header.
+func NewFixedWidthIterator[T any](input io.Reader,
+	layout *FixedLayout,
+	hasHeader bool,
+	conversionFunc ParseFunc[T],
+	opts ...ReaderOption) (iter.Seq2[Record[T], error], error) {
+
+	opts = append(slices.Clip(opts), WithFixedWidth(layout))
+	return NewDefaultIterator(input, hasHeader, conversionFunc, opts...)
+}
+
+// NewFixedWidthWriter creates a new Writer which writes records of type T as fixed-width records with layout.
+// Values longer than their column width are returned as an error.
+func NewFixedWidthWriter[T any](output io.Writer,
+	layout *FixedLayout,
+	convertFunc ConvertFunc[T],
+	opts ...WriterOption) (Writer[T], error) {
+
+	opts = append(slices.Clip(opts), WithFixedWidth(layout))
+	return NewWriter(output, convertFunc, opts...)
+}
+
+// validate returns an error if the layout's columns are not well-formed or overlap.
+func (l *FixedLayout) validate() error {
+	if len(l.Columns) == 0 {
+		return errors.New("fixed-width layout has no columns")
+	}
+
+	columns := slices.Clone(l.Columns)
+	slices.SortFunc(columns, func(a, b FixedColumn) int {
+		return a.Offset - b.Offset
+	})
+	end := 0
+	for _, c := range columns {
+		switch {
+		case c.Offset < 0 || c.Width <= 0:
+			return fmt.Errorf("fixed-width column %q has invalid offset %d or width %d", c.Name, c.Offset, c.Width)
+		case c.Align != AlignLeft && c.Align != AlignRight:
+			return fmt.Errorf("fixed-width column %q has invalid alignment %s", c.Name, c.Align)
+		case c.Pad != 0 && (!utf8.ValidRune(c.Pad) || c.Pad == '\r' || c.Pad == '\n'):
+			return fmt.Errorf("fixed-width column %q has invalid padding %q", c.Name, c.Pad)
+		case c.Offset < end:
+			return fmt.Errorf("fixed-width column %q overlaps the previous column", c.Name)
+		}
+		end = c.Offset + c.Width
+	}
+	if l.Length > 0 && l.Length < end {
+		return fmt.Errorf("fixed-width layout length %d is less than its columns' length %d", l.Length, end)
+	}
+	return nil
+}
+
+// pad returns the column's padding character.
+func (c FixedColumn) pad() rune {
+	if c.Pad == 0 {
+		return ' '
+	}
+	return c.Pad
+}
+
+// split returns the column values of a line with padding removed.
+func (l *FixedLayout) split(line string) ([]string, error) {
+	runes := []rune(line)
+	if l.Length > 0 && len(runes) != l.Length {
+		return nil, fmt.Errorf("line has %d characters, layout requires %d", len(runes), l.Length)
+	}
+
+	fields := make([]string, len(l.Columns))
+	for i, c := range l.Columns {
+		start, end := min(c.Offset, len(runes)), min(c.Offset+c.Width, len(runes))
+		value := string(runes[start:end])
+		pad := string(c.pad())
+		if c.Align == AlignRight {
+			fields[i] = strings.TrimLeft(value, pad)
+		} else {
+			fields[i] = strings.TrimRight(value, pad)
+		}
+		if fields[i] == "" && value != "" && c.KeepPad {
+			fields[i] = pad
+		}
+	}
+	return fields, nil
+}
+
+// fixedDecoder is a recordDecoder for fixed-width input.
+type fixedDecoder struct {
+	reader  *bufio.Reader
+	layout  *FixedLayout
+	comment rune
+	// offset and line are the bytes and lines read
+	offset int64
+	line   int
+}
+
+// decode implements recordDecoder.
+func (d *fixedDecoder) decode() (decodedRecord, error) {
+	for {
+		text, err := d.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || text == "") {
+			return decodedRecord{}, err
+		}
+		d.offset += int64(len(text))
+		d.line++
+
+		text = strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")
+		if text == "" || (d.comment != 0 && strings.HasPrefix(text, string(d.comment))) {
+			continue
+		}
+		fields, err := d.layout.split(text)
+		return decodedRecord{fields: fields, line: d.line, lastLine: d.line}, err
+	}
+}
+
+// inputOffset implements recordDecoder.
+func (d *fixedDecoder) inputOffset() int64 {
+	return d.offset
+}
+
+// writeFixedRecord writes csvFields to the output as a fixed-width record.
+func (w *Writer[T]) writeFixedRecord(csvFields []string) error {
+	layout := w.config.layout
+	if len(csvFields) != len(layout.Columns) {
+		return fmt.Errorf("record has %d fields, layout has %d columns", len(csvFields), len(layout.Columns))
+	}
+
+	length := layout.Length
+	for _, c := range layout.Columns {
+		length = max(length, c.Offset+c.Width)
+	}
+	line := slices.Repeat([]rune{' '}, length)
+	for i, c := range layout.Columns {
+		value := []rune(csvFields[i])
+		if len(value) > c.Width {
+			return fmt.Errorf("column %q value %q exceeds width %d", c.Name, csvFields[i], c.Width)
+		}
+		if slices.ContainsFunc(value, func(r rune) bool { return r == '\n' || r == '\r' }) {
+			return fmt.Errorf("column %q value %q contains a line break", c.Name, csvFields[i])
+		}
+
+		padding := slices.Repeat([]rune{c.pad()}, c.Width-len(value))
+		column := line[c.Offset : c.Offset+c.Width]
+		if c.Align == AlignRight {
+			copy(column, padding)
+			copy(column[len(padding):], value)
+		} else {
+			copy(column, value)
+			copy(column[len(value):], padding)
+		}
+	}
+
+	w.buffer.WriteString(string(line))
+	var err error
+	if w.config.useCRLF {
+		_, err = w.buffer.WriteString("\r\n")
+	} else {
+		err = w.buffer.WriteByte('\n')
+	}
+	return err
+}
@@ -0,0 +1,221 @@
+package csvlib
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fixedLayout has a left aligned name, a zero padded right aligned amount and a filler character at position 10.
+var fixedLayout = &FixedLayout{
+	Columns: []FixedColumn{
+		{Name: "name", Offset: 0, Width: 6},
+		{Name: "amount", Offset: 6, Width: 4, Align: AlignRight, Pad: '0'},
+	},
+	Length: 11,
+}
+
+func TestFixedWidthIterator(t *testing.T) {
+	input := "name  amnt \n" +
+		"ann   0012 \n" +
+		"\n" +
+		"# comment\n" +
+		"bob   0300 \r\n" +
+		"short\n" +
+		"zoë   00x1 \n"
+
+	parseFunc := func(fields []string) (CustomRecord, error) {
+		if strings.Contains(fields[1], "x") {
+			return CustomRecord{}, errors.New("test case invalid amount")
+		}
+		return CustomRecord{FirstName: fields[0], LastName: fields[1]}, nil
+	}
+	iter, err := NewFixedWidthIterator(strings.NewReader(input), fixedLayout, true, parseFunc, WithComment('#'),
+		WithRawFields())
+	if err != nil {
+		t.Fatalf("NewFixedWidthIterator unexpected error %v", err)
+	}
+
+	type result struct {
+		Record Record[CustomRecord]
+		Err    string
+	}
+	var got []result
+	for rec, err := range iter {
+		r := result{Record: rec}
+		var ie *IterationError
+		var pe *ParseError
+		switch {
+		case errors.As(err, &ie):
+			r.Err = "IterationError"
+		case errors.As(err, &pe):
+			r.Err = "ParseError"
+		}
+		got = append(got, r)
+	}
+
+	want := []result{
+		{Record: Record[CustomRecord]{LineNumber: 2, Offset: 12, Raw: []string{"ann", "12"},
+			Data: CustomRecord{FirstName: "ann", LastName: "12"}}},
+		{Record: Record[CustomRecord]{LineNumber: 5, Offset: 24, Raw: []string{"bob", "300"},
+			Data: CustomRecord{FirstName: "bob", LastName: "300"}}},
+		{Record: Record[CustomRecord]{LineNumber: 6, Offset: 48}, Err: "IterationError"},
+		{Record: Record[CustomRecord]{LineNumber: 7, Offset: 54, Raw: []string{"zoë", "x1"}}, Err: "ParseError"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("iterator found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestFixedWidthIterator_SharedOptions(t *testing.T) {
+	input := "1   ann   \n2   bob   \n3   Cal   \n"
+	layout := &FixedLayout{Columns: []FixedColumn{
+		{Name: "name", Offset: 4, Width: 6},
+		{Name: "id", Offset: 0, Width: 4},
+	}}
+
+	// struct tags, schema validation and checkpoints work with fixed-width input
+	schema := &Schema{Columns: []Column{{Name: "name", Pattern: regexp.MustCompile(`^[a-z]+$`)}}}
+	iter, err := NewStructIterator[TaggedBase](strings.NewReader("id  name  \n"+input),
+		WithFixedWidth(layout), WithSchema(schema), WithResume(Checkpoint{Offset: 22, LineNumber: 3}))
+	if err != nil {
+		t.Fatalf("NewStructIterator unexpected error %v", err)
+	}
+	var ids []uint16
+	var errs []error
+	for rec, err := range iter {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ids = append(ids, rec.Data.ID)
+	}
+	if diff := cmp.Diff([]uint16{2}, ids); diff != "" {
+		t.Errorf("iterator found diff (-want +got):\n%s", diff)
+	}
+	var ve *ValidationError
+	if len(errs) != 1 || !errors.As(errs[0], &ve) || ve.LineNumber() != 4 {
+		t.Errorf("iterator errors = %v, want ValidationError in line 4", errs)
+	}
+}
+
+func TestFixedLayout_Invalid(t *testing.T) {
+	tests := map[string]*FixedLayout{
+		"no columns":        {},
+		"invalid width":     {Columns: []FixedColumn{{Name: "a", Width: 0}}},
+		"negative offset":   {Columns: []FixedColumn{{Name: "a", Offset: -1, Width: 1}}},
+		"invalid alignment": {Columns: []FixedColumn{{Name: "a", Width: 1, Align: Alignment(3)}}},
+		"invalid padding":   {Columns: []FixedColumn{{Name: "a", Width: 1, Pad: '\n'}}},
+		"overlap": {Columns: []FixedColumn{
+			{Name: "a", Offset: 2, Width: 2},
+			{Name: "b", Offset: 0, Width: 3},
+		}},
+		"short length": {Columns: []FixedColumn{{Name: "a", Width: 4}}, Length: 3},
+	}
+	for name, layout := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewFixedWidthIterator(strings.NewReader(""), layout, false, customRecordParseFunc); err == nil {
+				t.Error("NewFixedWidthIterator expected error")
+			}
+			if _, err := NewFixedWidthWriter(&bytes.Buffer{}, layout, customRecordConvertFunc); err == nil {
+				t.Error("NewFixedWidthWriter expected error")
+			}
+		})
+	}
+}
+
+func TestFixedWidthWriter(t *testing.T) {
+	var output bytes.Buffer
+	writer, err := NewFixedWidthWriter(&output, fixedLayout, customRecordConvertFunc, WithCRLF())
+	if err != nil {
+		t.Fatalf("NewFixedWidthWriter unexpected error %v", err)
+	}
+	if err := writer.WriteHeader([]string{"name", "amnt"}); err != nil {
+		t.Fatalf("WriteHeader unexpected error %v", err)
+	}
+	for _, r := range []CustomRecord{{FirstName: "ann", LastName: "12"}, {FirstName: "zoë", LastName: ""}} {
+		if err := writer.Write(r); err != nil {
+			t.Fatalf("Write unexpected error %v", err)
+		}
+	}
+
+	tests := map[string]CustomRecord{
+		"value too long": {FirstName: "abcdefg", LastName: "1"},
+		"line break":     {FirstName: "a\nb", LastName: "1"},
+	}
+	for name, r := range tests {
+		if err := writer.Write(r); err == nil {
+			t.Errorf("Write expected error for %s", name)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+
+	want := "name  amnt \r\nann   0012 \r\nzoë   0000 \r\n"
+	if diff := cmp.Diff(want, output.String()); diff != "" {
+		t.Errorf("writer found diff (-want +got):\n%s", diff)
+	}
+
+	// the written records are read back with the same layout
+	iter, err := NewFixedWidthIterator(&output, fixedLayout, true, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewFixedWidthIterator unexpected error %v", err)
+	}
+	var got []CustomRecord
+	for rec, err := range iter {
+		if err != nil {
+			t.Fatalf("iterator error = %v", err)
+		}
+		got = append(got, rec.Data)
+	}
+	wantRecords := []CustomRecord{{FirstName: "ann", LastName: "12"}, {FirstName: "zoë", LastName: ""}}
+	if diff := cmp.Diff(wantRecords, got); diff != "" {
+		t.Errorf("iterator found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestFixedWidthWriter_KeepPad(t *testing.T) {
+	layout := &FixedLayout{Columns: []FixedColumn{
+		{Name: "name", Offset: 0, Width: 3},
+		{Name: "amount", Offset: 3, Width: 3, Align: AlignRight, Pad: '0', KeepPad: true},
+	}}
+
+	var output bytes.Buffer
+	writer, err := NewFixedWidthWriter(&output, layout, customRecordConvertFunc)
+	if err != nil {
+		t.Fatalf("NewFixedWidthWriter unexpected error %v", err)
+	}
+	records := []CustomRecord{{FirstName: "ann", LastName: "0"}, {FirstName: "bob", LastName: "10"}}
+	for _, r := range records {
+		if err := writer.Write(r); err != nil {
+			t.Fatalf("Write unexpected error %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+	if got, want := output.String(), "ann000\nbob010\n"; got != want {
+		t.Errorf("writer output = %q, want %q", got, want)
+	}
+
+	// zero values are read as zero rather than as empty values
+	iter, err := NewFixedWidthIterator(&output, layout, false, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewFixedWidthIterator unexpected error %v", err)
+	}
+	var got []CustomRecord
+	for rec, err := range iter {
+		if err != nil {
+			t.Fatalf("iterator error = %v", err)
+		}
+		got = append(got, rec.Data)
+	}
+	if diff := cmp.Diff(records, got); diff != "" {
+		t.Errorf("iterator found diff (-want +got):\n%s", diff)
+	}
+}
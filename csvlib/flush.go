@@ -0,0 +1,111 @@
+package csvlib
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dixonwhitmire/golib/loglib"
+)
+
+// FlushPolicy determines when a Writer flushes buffered records to its output.
+// The zero value flushes only when the buffer is full, on an explicit Flush, and on Close.
+type FlushPolicy struct {
+	// rows, if > 0, flushes once rows records have been written since the last flush.
+	rows int
+	// interval, if > 0, flushes on the first write once interval has elapsed since the last flush.
+	interval time.Duration
+}
+
+// FlushOnClose returns the default FlushPolicy, which flushes only when the buffer is full, on an explicit Flush, and on
+// Close.
+func FlushOnClose() FlushPolicy {
+	return FlushPolicy{}
+}
+
+// FlushEveryRows returns a FlushPolicy which flushes after every n records, including the header.
+// FlushEveryRows is equivalent to FlushEachWrite if n < 1.
+func FlushEveryRows(n int) FlushPolicy {
+	return FlushPolicy{rows: max(n, 1)}
+}
+
+// FlushInterval returns a FlushPolicy which flushes on the first write once interval has elapsed since the last flush.
+// The interval is checked as records are written; records are not flushed by a background goroutine while the Writer
+// is idle. FlushInterval is equivalent to FlushEachWrite if interval <= 0.
+func FlushInterval(interval time.Duration) FlushPolicy {
+	if interval <= 0 {
+		return FlushEachWrite()
+	}
+	return FlushPolicy{interval: interval}
+}
+
+// FlushEachWrite returns a FlushPolicy which flushes after every record, for consumers which tail the output.
+func FlushEachWrite() FlushPolicy {
+	return FlushPolicy{rows: 1}
+}
+
+// WithFlushPolicy sets the FlushPolicy used by the Writer.
+func WithFlushPolicy(policy FlushPolicy) WriterOption {
+	return option{writer: func(c *writerConfig) { c.flushPolicy = policy }}
+}
+
+// WithStatsLogging logs the Writer's WriterStats at level when the Writer is closed, using the default slog logger
+// configured by loglib. The log event includes eventName as its event source, the elapsed time since the Writer was
+// created, and the error returned by Close, if any.
+func WithStatsLogging(eventName string, level slog.Level) WriterOption {
+	return option{writer: func(c *writerConfig) {
+		c.logEvent = eventName
+		c.logLevel = level
+	}}
+}
+
+// WriterStats counts the output of a Writer.
+type WriterStats struct {
+	// Rows is the number of records written by Write, excluding the header.
+	Rows int64
+	// Bytes is the number of bytes written, including the header and buffered bytes, before compression.
+	Bytes int64
+	// ConversionErrors is the number of records which the ConvertFunc failed to convert.
+	ConversionErrors int64
+}
+
+// Stats returns the Writer's WriterStats.
+func (w *Writer[T]) Stats() WriterStats {
+	stats := w.stats
+	stats.Bytes = w.bytesWritten()
+	return stats
+}
+
+// applyFlushPolicy flushes the Writer if a record written since the last flush is due to be flushed by the
+// FlushPolicy.
+func (w *Writer[T]) applyFlushPolicy() error {
+	policy := w.config.flushPolicy
+	w.unflushed++
+	due := (policy.rows > 0 && w.unflushed >= policy.rows) ||
+		(policy.interval > 0 && time.Since(w.lastFlush) >= policy.interval)
+	if !due {
+		return nil
+	}
+	w.Flush()
+	return w.outputWriter.Error()
+}
+
+// logStats logs the Writer's WriterStats if the Writer was created WithStatsLogging.
+func (w *Writer[T]) logStats(closeErr error) {
+	if w.config.logEvent == "" {
+		return
+	}
+	stats := w.Stats()
+	args := []any{
+		loglib.LogEventSourceKey, w.config.logEvent,
+		"rows", stats.Rows,
+		"bytes", stats.Bytes,
+		"conversion_errors", stats.ConversionErrors,
+		loglib.LogElapsedTimeKey, fmt.Sprintf(loglib.LogTimeFormat, time.Since(w.created).Seconds()),
+	}
+	if closeErr != nil {
+		args = append(args, loglib.LogErrorKey, closeErr)
+	}
+	slog.Log(context.Background(), w.config.logLevel, "csv writer stats", args...)
+}
@@ -0,0 +1,151 @@
+package csvlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriter_FlushPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy FlushPolicy
+		// want is the output flushed after each of three writes
+		want []string
+	}{
+		{
+			name:   "on close",
+			policy: FlushOnClose(),
+			want:   []string{"", "", ""},
+		},
+		{
+			name:   "each write",
+			policy: FlushEachWrite(),
+			want:   []string{"1,a\n", "1,a\n2,b\n", "1,a\n2,b\n3,c\n"},
+		},
+		{
+			name:   "every rows",
+			policy: FlushEveryRows(2),
+			want:   []string{"", "1,a\n2,b\n", "1,a\n2,b\n"},
+		},
+		{
+			name:   "long interval",
+			policy: FlushInterval(time.Hour),
+			want:   []string{"", "", ""},
+		},
+		{
+			name:   "zero interval",
+			policy: FlushInterval(0),
+			want:   []string{"1,a\n", "1,a\n2,b\n", "1,a\n2,b\n3,c\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			writer, err := NewWriter(&output, customRecordConvertFunc, WithFlushPolicy(tt.policy))
+			if err != nil {
+				t.Fatalf("NewWriter unexpected error %v", err)
+			}
+
+			records := []CustomRecord{{FirstName: "1", LastName: "a"}, {FirstName: "2", LastName: "b"}, {FirstName: "3", LastName: "c"}}
+			for i, rec := range records {
+				if err := writer.Write(rec); err != nil {
+					t.Fatalf("Write unexpected error %v", err)
+				}
+				if got := output.String(); got != tt.want[i] {
+					t.Errorf("output after write %d = %q, want %q", i+1, got, tt.want[i])
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close unexpected error %v", err)
+			}
+			if got := output.String(); got != "1,a\n2,b\n3,c\n" {
+				t.Errorf("output after Close = %q", got)
+			}
+		})
+	}
+}
+
+func TestWriter_FlushInterval(t *testing.T) {
+	var output bytes.Buffer
+	writer, err := NewWriter(&output, customRecordConvertFunc, WithFlushPolicy(FlushInterval(time.Millisecond)))
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if err := writer.Write(CustomRecord{FirstName: "1", LastName: "a"}); err != nil {
+		t.Fatalf("Write unexpected error %v", err)
+	}
+	if got := output.String(); got != "1,a\n" {
+		t.Errorf("output after interval = %q, want %q", got, "1,a\n")
+	}
+}
+
+func TestWriter_Stats(t *testing.T) {
+	var output bytes.Buffer
+	convertFunc := func(rec CustomRecord) ([]string, error) {
+		if rec.FirstName == "" {
+			return nil, errors.New("first name is required")
+		}
+		return customRecordConvertFunc(rec)
+	}
+	writer, err := NewWriter(&output, convertFunc, WithGzip())
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+
+	if err := writer.WriteHeader([]string{"first", "last"}); err != nil {
+		t.Fatalf("WriteHeader unexpected error %v", err)
+	}
+	for _, rec := range []CustomRecord{{FirstName: "1", LastName: "a"}, {}, {FirstName: "2", LastName: "b"}} {
+		writer.Write(rec)
+	}
+
+	want := WriterStats{Rows: 2, Bytes: int64(len("first,last\n1,a\n2,b\n")), ConversionErrors: 1}
+	if diff := cmp.Diff(want, writer.Stats()); diff != "" {
+		t.Errorf("Stats found diff (-want +got):\n%s", diff)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+	if diff := cmp.Diff(want, writer.Stats()); diff != "" {
+		t.Errorf("Stats after Close found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriter_StatsLogging(t *testing.T) {
+	var logOutput bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logOutput, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	var output bytes.Buffer
+	writer, err := NewWriter(&output, customRecordConvertFunc, WithStatsLogging("export", slog.LevelInfo))
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+	writer.Write(CustomRecord{FirstName: "1", LastName: "a"})
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+
+	var event map[string]any
+	if err := json.Unmarshal(logOutput.Bytes(), &event); err != nil {
+		t.Fatalf("log output %q is not JSON: %v", logOutput.String(), err)
+	}
+	want := map[string]any{"event_source": "export", "rows": 1.0, "bytes": 4.0, "conversion_errors": 0.0}
+	for key, value := range want {
+		if event[key] != value {
+			t.Errorf("log %s = %v, want %v", key, event[key], value)
+		}
+	}
+	if _, ok := event["elapsed_time"]; !ok {
+		t.Error("log does not include elapsed_time")
+	}
+}
@@ -0,0 +1,127 @@
+package csvlib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// ErrMissingColumn is returned when a column is not present in a CSV header.
+var ErrMissingColumn = errors.New("missing column")
+
+// Header maps CSV header column names to their field positions.
+type Header struct {
+	names []string
+	index map[string]int
+}
+
+// NewHeader returns a Header for the specified column names.
+// If a column name is repeated, lookups resolve to the first occurrence.
+func NewHeader(names []string) *Header {
+	h := &Header{names: append([]string(nil), names...), index: make(map[string]int, len(names))}
+	for i, name := range h.names {
+		if _, ok := h.index[name]; !ok {
+			h.index[name] = i
+		}
+	}
+	return h
+}
+
+// Names returns the header column names in file order.
+func (h *Header) Names() []string {
+	return append([]string(nil), h.names...)
+}
+
+// Index returns the field position of a column and whether the column exists.
+func (h *Header) Index(column string) (int, bool) {
+	i, ok := h.index[column]
+	return i, ok
+}
+
+// Require returns an error wrapping ErrMissingColumn if any of the specified columns are not in the header.
+func (h *Header) Require(columns ...string) error {
+	var missing []string
+	for _, column := range columns {
+		if _, ok := h.index[column]; !ok {
+			missing = append(missing, fmt.Sprintf("%q", column))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("Header.Require: %w %s", ErrMissingColumn, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Row is a CSV record whose fields may be accessed by header column name.
+type Row struct {
+	header *Header
+	fields []string
+}
+
+// NewRow returns a Row for the specified header and record fields.
+func NewRow(header *Header, fields []string) Row {
+	return Row{header: header, fields: fields}
+}
+
+// Header returns the Row's header.
+func (r Row) Header() *Header {
+	return r.header
+}
+
+// Fields returns the Row's raw record fields.
+func (r Row) Fields() []string {
+	return r.fields
+}
+
+// Lookup returns the value of a column and whether the column is present in the row.
+func (r Row) Lookup(column string) (string, bool) {
+	if r.header == nil {
+		return "", false
+	}
+	i, ok := r.header.Index(column)
+	if !ok || i >= len(r.fields) {
+		return "", false
+	}
+	return r.fields[i], true
+}
+
+// Get returns the value of a column.
+// An error wrapping ErrMissingColumn is returned if the column is not present in the row.
+func (r Row) Get(column string) (string, error) {
+	value, ok := r.Lookup(column)
+	if !ok {
+		return "", fmt.Errorf("Row.Get: %w %q", ErrMissingColumn, column)
+	}
+	return value, nil
+}
+
+// RowParseFunc is used to parse a header-aware CSV Row into type T.
+type RowParseFunc[T any] func(Row) (T, error)
+
+// NewHeaderIterator returns an iterator which reads the first record as a header and passes each subsequent record to
+// parseFunc as a Row.
+// requiredColumns, if provided, are checked against the header before any records are parsed. A missing required
+// column is returned as an IterationError wrapping ErrMissingColumn.
+// opts configure the CSV dialect and iteration behavior.
+func NewHeaderIterator[T any](input io.Reader,
+	requiredColumns []string,
+	parseFunc RowParseFunc[T],
+	opts ...ReaderOption) (iter.Seq2[Record[T], error], error) {
+
+	if parseFunc == nil {
+		err := errors.New("NewHeaderIterator: parseFunc is required")
+		return nil, NewIterationError(0, err)
+	}
+
+	var header *Header
+	headerFunc := func(fields []string) error {
+		header = NewHeader(fields)
+		return header.Require(requiredColumns...)
+	}
+	conversionFunc := func(fields []string) (T, error) {
+		return parseFunc(NewRow(header, fields))
+	}
+	return iterator[T](input, true, DefaultBufferSize, headerFunc, conversionFunc, opts)
+}
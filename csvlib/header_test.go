@@ -0,0 +1,107 @@
+package csvlib
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// customRecordRowParseFunc is used to parse a header-aware Row to a CustomRecord.
+func customRecordRowParseFunc(row Row) (CustomRecord, error) {
+	firstName, err := row.Get("first_name")
+	if err != nil {
+		return CustomRecord{}, err
+	}
+	lastName, err := row.Get("last_name")
+	if err != nil {
+		return CustomRecord{}, err
+	}
+	return CustomRecord{FirstName: firstName, LastName: lastName}, nil
+}
+
+func TestHeaderIterator(t *testing.T) {
+	tests := map[string]struct {
+		csvData string
+		want    []Record[CustomRecord]
+	}{
+		"header-order": {
+			csvData: sampleCsv(t, true),
+			want: []Record[CustomRecord]{
+				{LineNumber: 2, Offset: 25, Data: CustomRecord{FirstName: "John", LastName: "Doe"}},
+				{LineNumber: 3, Offset: 38, Data: CustomRecord{FirstName: "Jane", LastName: "Doe"}},
+			},
+		},
+		"reordered-columns": {
+			csvData: "last_name,id,first_name\nDoe,1,John\n",
+			want: []Record[CustomRecord]{
+				{LineNumber: 2, Offset: 24, Data: CustomRecord{FirstName: "John", LastName: "Doe"}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			iter, err := NewHeaderIterator(strings.NewReader(tt.csvData), []string{"first_name"}, customRecordRowParseFunc)
+			if err != nil {
+				t.Fatalf("NewHeaderIterator unexpected error %v", err)
+			}
+
+			got := make([]Record[CustomRecord], 0, len(tt.want))
+			for rec, err := range iter {
+				if err != nil {
+					t.Fatalf("iterator error = %v", err)
+				}
+				got = append(got, rec)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("iterator found diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHeaderIterator_MissingRequiredColumn(t *testing.T) {
+	iter, err := NewHeaderIterator(strings.NewReader(sampleCsv(t, true)), []string{"email"}, customRecordRowParseFunc)
+	if err != nil {
+		t.Fatalf("NewHeaderIterator unexpected error %v", err)
+	}
+
+	count := 0
+	for _, err := range iter {
+		count++
+		var ie *IterationError
+		if !errors.As(err, &ie) || !errors.Is(err, ErrMissingColumn) {
+			t.Errorf("expected an IterationError wrapping ErrMissingColumn, got %v", err)
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected iteration to stop after the header error, got %d results", count)
+	}
+}
+
+func TestHeaderIterator_MissingColumnParseError(t *testing.T) {
+	iter, err := NewHeaderIterator(strings.NewReader("first_name\nJohn\n"), nil, customRecordRowParseFunc)
+	if err != nil {
+		t.Fatalf("NewHeaderIterator unexpected error %v", err)
+	}
+
+	for _, err := range iter {
+		var pe *ParseError
+		if !errors.As(err, &pe) || !errors.Is(err, ErrMissingColumn) {
+			t.Errorf("expected a ParseError wrapping ErrMissingColumn, got %v", err)
+		}
+	}
+}
+
+func TestRow_Lookup(t *testing.T) {
+	row := NewRow(NewHeader([]string{"a", "b", "a"}), []string{"1", "2", "3"})
+
+	if got, ok := row.Lookup("a"); !ok || got != "1" {
+		t.Errorf("Lookup(a) = %q, %v; want \"1\", true", got, ok)
+	}
+	if _, ok := row.Lookup("c"); ok {
+		t.Error("Lookup(c) found a column which is not in the header")
+	}
+}
@@ -0,0 +1,265 @@
+package csvlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// InferenceLayouts lists the time layouts recognized by InferSchema, in order of preference.
+var InferenceLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	"01/02/2006",
+	"02-Jan-2006",
+	"Jan 2, 2006",
+}
+
+// ColumnInference describes a column's characteristics inferred from sample records.
+type ColumnInference struct {
+	Name string
+	Type ColumnType
+	// Layout is the time layout for TimeType columns.
+	Layout string
+	// Nullable is true if the column contains empty values.
+	Nullable bool
+	// Cardinality is the number of distinct non-empty values in the sample.
+	Cardinality int
+}
+
+// Inference describes a CSV file's columns inferred from sample records.
+type Inference struct {
+	// Records is the number of sample records read.
+	Records int
+	Columns []ColumnInference
+}
+
+// InferSchema samples up to sampleSize records following the header in input and infers each column's type,
+// nullability, cardinality and time layout. All records are sampled if sampleSize <= 0.
+// Malformed records are not sampled.
+func InferSchema(input io.Reader, sampleSize int, opts ...ReaderOption) (*Inference, error) {
+	var inferrers []*columnInferrer
+	headerFunc := func(fields []string) error {
+		inferrers = make([]*columnInferrer, len(fields))
+		for i, name := range fields {
+			inferrers[i] = newColumnInferrer(name)
+		}
+		return nil
+	}
+
+	parseFunc := func(fields []string) ([]string, error) {
+		return fields, nil
+	}
+
+	records, err := iterator(input, true, DefaultBufferSize, headerFunc, parseFunc, opts)
+	if err != nil {
+		return nil, fmt.Errorf("InferSchema: %w", err)
+	}
+
+	inference := &Inference{}
+	for rec, err := range records {
+		if err != nil {
+			if isRecordError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("InferSchema: %w", err)
+		}
+		inference.Records++
+		for i, value := range rec.Data {
+			if i < len(inferrers) {
+				inferrers[i].observe(value)
+			}
+		}
+		if sampleSize > 0 && inference.Records >= sampleSize {
+			break
+		}
+	}
+	if inferrers == nil {
+		return nil, errors.New("InferSchema: input does not have a header")
+	}
+
+	inference.Columns = make([]ColumnInference, len(inferrers))
+	for i, ci := range inferrers {
+		inference.Columns[i] = ci.result()
+	}
+	return inference, nil
+}
+
+// Schema returns a Schema which requires each inferred column and validates its type and nullability.
+func (inf *Inference) Schema() *Schema {
+	schema := &Schema{Columns: make([]Column, len(inf.Columns))}
+	for i, c := range inf.Columns {
+		schema.Columns[i] = Column{
+			Name:     c.Name,
+			Type:     c.Type,
+			Layout:   c.Layout,
+			Required: true,
+			Nullable: c.Nullable,
+		}
+	}
+	return schema
+}
+
+// GoStruct returns the source of a Go struct type with csv struct tags for the inferred columns, for use with
+// NewStructIterator and NewStructWriter. Nullable columns, other than strings, are declared as pointers.
+func (inf *Inference) GoStruct(typeName string) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "type %s struct {\n", typeName)
+
+	used := make(map[string]int)
+	for _, c := range inf.Columns {
+		fieldName := goFieldName(c.Name)
+		used[fieldName]++
+		if n := used[fieldName]; n > 1 {
+			fieldName += strconv.Itoa(n)
+		}
+
+		var fieldType string
+		switch c.Type {
+		case IntType:
+			fieldType = "int64"
+		case FloatType:
+			fieldType = "float64"
+		case BoolType:
+			fieldType = "bool"
+		case TimeType:
+			fieldType = "time.Time"
+		default:
+			fieldType = "string"
+		}
+		if c.Nullable && c.Type != StringType {
+			fieldType = "*" + fieldType
+		}
+
+		tag := fmt.Sprintf("%s:%q", tagName, c.Name)
+		if c.Type == TimeType {
+			tag += fmt.Sprintf(" %s:%q", layoutTagName, c.Layout)
+		}
+		fmt.Fprintf(&buf, "%s %s `%s`\n", fieldName, fieldType, tag)
+	}
+	buf.WriteString("}\n")
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return buf.String()
+	}
+	return string(formatted)
+}
+
+// goFieldName converts a column name to an exported Go identifier.
+func goFieldName(column string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range column {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+
+	name := sb.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "Column" + name
+	}
+	return name
+}
+
+// columnInferrer accumulates the observations for a single column.
+type columnInferrer struct {
+	name     string
+	values   int
+	nullable bool
+	distinct map[string]struct{}
+	isInt    bool
+	isFloat  bool
+	isBool   bool
+	// layouts contains the InferenceLayouts which have parsed every value
+	layouts []string
+}
+
+// newColumnInferrer returns a columnInferrer which initially accepts every type.
+func newColumnInferrer(name string) *columnInferrer {
+	return &columnInferrer{
+		name:     name,
+		distinct: make(map[string]struct{}),
+		isInt:    true,
+		isFloat:  true,
+		isBool:   true,
+		layouts:  append([]string(nil), InferenceLayouts...),
+	}
+}
+
+// observe narrows the column's candidate types using a sample value.
+func (c *columnInferrer) observe(value string) {
+	if value == "" {
+		c.nullable = true
+		return
+	}
+	c.values++
+	c.distinct[value] = struct{}{}
+
+	// numbers with leading zeros, such as ZIP codes and account numbers, are identifiers which parsing would alter
+	if hasLeadingZero(value) {
+		c.isInt = false
+		c.isFloat = false
+	}
+	if c.isInt {
+		_, err := strconv.ParseInt(value, 10, 64)
+		c.isInt = err == nil
+	}
+	if c.isFloat {
+		_, err := strconv.ParseFloat(value, 64)
+		c.isFloat = err == nil
+	}
+	if c.isBool {
+		_, err := strconv.ParseBool(value)
+		c.isBool = err == nil
+	}
+	layouts := c.layouts[:0]
+	for _, layout := range c.layouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			layouts = append(layouts, layout)
+		}
+	}
+	c.layouts = layouts
+}
+
+// hasLeadingZero reports whether value begins with a zero followed by another digit, after an optional sign.
+func hasLeadingZero(value string) bool {
+	value = strings.TrimLeft(value, "+-")
+	return len(value) > 1 && value[0] == '0' && value[1] >= '0' && value[1] <= '9'
+}
+
+// result returns the ColumnInference for the observed values.
+// Columns without non-empty values are inferred as nullable strings.
+func (c *columnInferrer) result() ColumnInference {
+	ci := ColumnInference{Name: c.name, Type: StringType, Nullable: c.nullable, Cardinality: len(c.distinct)}
+	switch {
+	case c.values == 0:
+		ci.Nullable = true
+	case c.isInt:
+		ci.Type = IntType
+	case c.isFloat:
+		ci.Type = FloatType
+	case c.isBool:
+		ci.Type = BoolType
+	case len(c.layouts) > 0:
+		ci.Type = TimeType
+		ci.Layout = c.layouts[0]
+	}
+	return ci
+}
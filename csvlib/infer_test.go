@@ -0,0 +1,82 @@
+package csvlib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const inferCsv = `id,first name,score,active,joined,updated,note
+1,John,9.5,true,2020-01-02,2020-01-02T10:00:00Z,
+2,Jane,7,false,2021-03-04,,hello
+3,Jim,8.25,true,2022-05-06,2020-01-03T11:30:00Z,
+3,Jim,8.25,true,2022-05-06,2020-01-03T11:30:00Z,x
+`
+
+func TestInferSchema(t *testing.T) {
+	inference, err := InferSchema(strings.NewReader(inferCsv), 3)
+	if err != nil {
+		t.Fatalf("InferSchema unexpected error %v", err)
+	}
+
+	want := &Inference{
+		Records: 3,
+		Columns: []ColumnInference{
+			{Name: "id", Type: IntType, Cardinality: 3},
+			{Name: "first name", Type: StringType, Cardinality: 3},
+			{Name: "score", Type: FloatType, Cardinality: 3},
+			{Name: "active", Type: BoolType, Cardinality: 2},
+			{Name: "joined", Type: TimeType, Layout: "2006-01-02", Cardinality: 3},
+			{Name: "updated", Type: TimeType, Layout: "2006-01-02T15:04:05Z07:00", Nullable: true, Cardinality: 2},
+			{Name: "note", Type: StringType, Nullable: true, Cardinality: 1},
+		},
+	}
+	if diff := cmp.Diff(want, inference); diff != "" {
+		t.Errorf("InferSchema found diff (-want +got):\n%s", diff)
+	}
+
+	// the inferred schema validates the sampled file
+	report, err := ValidateFile(strings.NewReader(inferCsv), true, inference.Schema())
+	if err != nil {
+		t.Fatalf("ValidateFile unexpected error %v", err)
+	}
+	if !report.Valid() {
+		t.Errorf("inferred schema did not validate the sample file: %v", report.Errors)
+	}
+}
+
+func TestInference_GoStruct(t *testing.T) {
+	inference, err := InferSchema(strings.NewReader(inferCsv), 0)
+	if err != nil {
+		t.Fatalf("InferSchema unexpected error %v", err)
+	}
+
+	want := "type Partner struct {\n" +
+		"\tId        int64      `csv:\"id\"`\n" +
+		"\tFirstName string     `csv:\"first name\"`\n" +
+		"\tScore     float64    `csv:\"score\"`\n" +
+		"\tActive    bool       `csv:\"active\"`\n" +
+		"\tJoined    time.Time  `csv:\"joined\" layout:\"2006-01-02\"`\n" +
+		"\tUpdated   *time.Time `csv:\"updated\" layout:\"2006-01-02T15:04:05Z07:00\"`\n" +
+		"\tNote      string     `csv:\"note\"`\n" +
+		"}\n"
+	if diff := cmp.Diff(want, inference.GoStruct("Partner")); diff != "" {
+		t.Errorf("GoStruct found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestInferSchema_LeadingZeros(t *testing.T) {
+	csvData := "zip,account,amount\n02134,007,0\n10001,120,0.5\n"
+	inference, err := InferSchema(strings.NewReader(csvData), 0)
+	if err != nil {
+		t.Fatalf("InferSchema unexpected error %v", err)
+	}
+
+	want := []ColumnType{StringType, StringType, FloatType}
+	for i, c := range inference.Columns {
+		if c.Type != want[i] {
+			t.Errorf("column %q type = %s, want %s", c.Name, c.Type, want[i])
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package csvlib
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// ErrUnsortedInput is returned by the MergeJoin strategy when an input is not sorted by key.
+var ErrUnsortedInput = errors.New("input is not sorted by key")
+
+// JoinType determines which records are returned by Join.
+type JoinType int
+
+const (
+	// InnerJoin returns left records which have a matching right record.
+	InnerJoin JoinType = iota
+	// LeftJoin returns every left record, with a nil Right if there is no matching right record.
+	LeftJoin
+)
+
+// String returns the JoinType name.
+func (jt JoinType) String() string {
+	switch jt {
+	case InnerJoin:
+		return "inner"
+	case LeftJoin:
+		return "left"
+	}
+	return fmt.Sprintf("JoinType(%d)", int(jt))
+}
+
+// JoinStrategy determines how records are matched by key.
+type JoinStrategy int
+
+const (
+	// HashJoin reads the right input into memory and streams the left input. It suits a right input which fits in
+	// memory, and does not require sorted input.
+	HashJoin JoinStrategy = iota
+	// MergeJoin streams both inputs, which must be sorted by key in ascending order, using memory only for records
+	// with the same key. It suits large inputs, which may be sorted with SortFile using StringType keys.
+	// An IterationError wrapping ErrUnsortedInput ends iteration if an input is not sorted.
+	MergeJoin
+)
+
+// String returns the JoinStrategy name.
+func (js JoinStrategy) String() string {
+	switch js {
+	case HashJoin:
+		return "hash"
+	case MergeJoin:
+		return "merge"
+	}
+	return fmt.Sprintf("JoinStrategy(%d)", int(js))
+}
+
+// Joined is a left record joined with a matching right record. Right is nil for a LeftJoin record without a match.
+type Joined[L, R any] struct {
+	Key   string
+	Left  Record[L]
+	Right *Record[R]
+}
+
+// Join returns an iterator which joins left and right records with the same key. A left record which matches several
+// right records is yielded once for each match, in right input order.
+// Errors from either input are passed through. An error which ends either input's iteration, such as an
+// IterationError for an invalid header, ends the join. Records are yielded in left input order for HashJoin, and in
+// key order for MergeJoin.
+func Join[L, R any](left iter.Seq2[Record[L], error],
+	right iter.Seq2[Record[R], error],
+	leftKey func(L) string,
+	rightKey func(R) string,
+	joinType JoinType,
+	strategy JoinStrategy) (iter.Seq2[Joined[L, R], error], error) {
+
+	if left == nil || right == nil {
+		return nil, errors.New("Join: left and right iterators are required")
+	}
+	if leftKey == nil || rightKey == nil {
+		return nil, errors.New("Join: leftKey and rightKey are required")
+	}
+	if joinType != InnerJoin && joinType != LeftJoin {
+		return nil, fmt.Errorf("Join: invalid join type %s", joinType)
+	}
+
+	switch strategy {
+	case HashJoin:
+		return hashJoin(left, right, leftKey, rightKey, joinType), nil
+	case MergeJoin:
+		return mergeJoin(left, right, leftKey, rightKey, joinType), nil
+	}
+	return nil, fmt.Errorf("Join: invalid join strategy %s", strategy)
+}
+
+// hashJoin joins left records with right records read into memory.
+func hashJoin[L, R any](left iter.Seq2[Record[L], error],
+	right iter.Seq2[Record[R], error],
+	leftKey func(L) string,
+	rightKey func(R) string,
+	joinType JoinType) iter.Seq2[Joined[L, R], error] {
+
+	return func(yield func(Joined[L, R], error) bool) {
+		matches := make(map[string][]Record[R])
+		for record, err := range right {
+			if err != nil {
+				if !yield(Joined[L, R]{}, err) || !isRecordError(err) {
+					return
+				}
+				continue
+			}
+			key := rightKey(record.Data)
+			matches[key] = append(matches[key], record)
+		}
+
+		for record, err := range left {
+			if err != nil {
+				if !yield(Joined[L, R]{Left: record}, err) {
+					return
+				}
+				continue
+			}
+			key := leftKey(record.Data)
+			if !yieldJoined(yield, key, record, matches[key], joinType) {
+				return
+			}
+		}
+	}
+}
+
+// yieldJoined yields left joined with each of its matches, or alone for a LeftJoin without matches.
+func yieldJoined[L, R any](yield func(Joined[L, R], error) bool,
+	key string,
+	left Record[L],
+	matches []Record[R],
+	joinType JoinType) bool {
+
+	if len(matches) == 0 {
+		if joinType == LeftJoin {
+			return yield(Joined[L, R]{Key: key, Left: left}, nil)
+		}
+		return true
+	}
+	for i := range matches {
+		if !yield(Joined[L, R]{Key: key, Left: left, Right: &matches[i]}, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// keyedCursor reads records from an input sorted by key.
+type keyedCursor[T any] struct {
+	next    func() (Record[T], error, bool)
+	keyFunc func(T) string
+	record  Record[T]
+	key     string
+	// started is true once a record has been read.
+	started bool
+	done    bool
+}
+
+// advance reads the next record, returning errors from the input. An IterationError wrapping ErrUnsortedInput is
+// returned if the record's key is less than the previous record's key.
+func (c *keyedCursor[T]) advance() error {
+	record, err, ok := c.next()
+	if !ok {
+		c.done = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	key := c.keyFunc(record.Data)
+	if c.started && key < c.key {
+		ie := NewIterationError(0, fmt.Errorf("%w: key %q follows %q", ErrUnsortedInput, key, c.key))
+		ie.setPosition(recordPosition(record))
+		c.done = true
+		return ie
+	}
+	c.record, c.key, c.started = record, key, true
+	return nil
+}
+
+// advanceCursor advances c, yielding input errors. It reports whether iteration should continue, which it does not
+// after an error which ends the input's iteration.
+func advanceCursor[T, U any](c *keyedCursor[T], yield func(U, error) bool) bool {
+	for {
+		err := c.advance()
+		if err == nil {
+			return true
+		}
+		var zero U
+		if !yield(zero, err) || !isRecordError(err) {
+			return false
+		}
+	}
+}
+
+// mergeJoin joins left and right records from inputs sorted by key.
+func mergeJoin[L, R any](left iter.Seq2[Record[L], error],
+	right iter.Seq2[Record[R], error],
+	leftKey func(L) string,
+	rightKey func(R) string,
+	joinType JoinType) iter.Seq2[Joined[L, R], error] {
+
+	return func(yield func(Joined[L, R], error) bool) {
+		leftNext, leftStop := iter.Pull2(left)
+		defer leftStop()
+		rightNext, rightStop := iter.Pull2(right)
+		defer rightStop()
+
+		l := &keyedCursor[L]{next: leftNext, keyFunc: leftKey}
+		r := &keyedCursor[R]{next: rightNext, keyFunc: rightKey}
+		if !advanceCursor(l, yield) || !advanceCursor(r, yield) {
+			return
+		}
+
+		// group holds the right records matching groupKey, which is valid if grouped is true
+		var group []Record[R]
+		groupKey, grouped := "", false
+		for !l.done {
+			if !grouped || l.key != groupKey {
+				for !r.done && r.key < l.key {
+					if !advanceCursor(r, yield) {
+						return
+					}
+				}
+				group, groupKey, grouped = nil, l.key, true
+				for !r.done && r.key == l.key {
+					group = append(group, r.record)
+					if !advanceCursor(r, yield) {
+						return
+					}
+				}
+			}
+			if !yieldJoined(yield, l.key, l.record, group, joinType) || !advanceCursor(l, yield) {
+				return
+			}
+		}
+	}
+}
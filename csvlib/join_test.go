@@ -0,0 +1,150 @@
+package csvlib
+
+import (
+	"errors"
+	"iter"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// joinRow is a test record with an id key.
+type joinRow struct {
+	ID    string
+	Value string
+}
+
+func joinRows(t *testing.T, csvData string) iter.Seq2[Record[joinRow], error] {
+	t.Helper()
+	records, err := NewDefaultIterator(strings.NewReader(csvData), true, func(fields []string) (joinRow, error) {
+		return joinRow{ID: fields[0], Value: fields[1]}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	return records
+}
+
+func joinRowKey(r joinRow) string {
+	return r.ID
+}
+
+// joinResult is a Joined record's values for comparison.
+type joinResult struct {
+	Key   string
+	Left  string
+	Right string
+}
+
+func TestJoin(t *testing.T) {
+	left := "id,name\n1,ann\n2,bob\n2,bea\n4,dan\n"
+	right := "id,order\n0,o0\n2,o1\n2,o2\n3,o3\n4,o4\n"
+
+	tests := map[string]struct {
+		joinType JoinType
+		want     []joinResult
+	}{
+		"inner": {
+			joinType: InnerJoin,
+			want: []joinResult{
+				{Key: "2", Left: "bob", Right: "o1"},
+				{Key: "2", Left: "bob", Right: "o2"},
+				{Key: "2", Left: "bea", Right: "o1"},
+				{Key: "2", Left: "bea", Right: "o2"},
+				{Key: "4", Left: "dan", Right: "o4"},
+			},
+		},
+		"left": {
+			joinType: LeftJoin,
+			want: []joinResult{
+				{Key: "1", Left: "ann"},
+				{Key: "2", Left: "bob", Right: "o1"},
+				{Key: "2", Left: "bob", Right: "o2"},
+				{Key: "2", Left: "bea", Right: "o1"},
+				{Key: "2", Left: "bea", Right: "o2"},
+				{Key: "4", Left: "dan", Right: "o4"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		for _, strategy := range []JoinStrategy{HashJoin, MergeJoin} {
+			t.Run(name+" "+strategy.String(), func(t *testing.T) {
+				joined, err := Join(joinRows(t, left), joinRows(t, right), joinRowKey, joinRowKey, tc.joinType, strategy)
+				if err != nil {
+					t.Fatalf("Join unexpected error %v", err)
+				}
+				var got []joinResult
+				for j, err := range joined {
+					if err != nil {
+						t.Fatalf("Join iterator error = %v", err)
+					}
+					result := joinResult{Key: j.Key, Left: j.Left.Data.Value}
+					if j.Right != nil {
+						result.Right = j.Right.Data.Value
+					}
+					got = append(got, result)
+				}
+				if diff := cmp.Diff(tc.want, got); diff != "" {
+					t.Errorf("Join found diff (-want +got):\n%s", diff)
+				}
+			})
+		}
+	}
+}
+
+func TestJoin_Errors(t *testing.T) {
+	left := "id,name\n1,ann\n2\n3,cal\n"
+	right := "id,order\n3,o3\n1,o1\n"
+
+	// malformed records are passed through
+	joined, err := Join(joinRows(t, left), joinRows(t, right), joinRowKey, joinRowKey, InnerJoin, HashJoin)
+	if err != nil {
+		t.Fatalf("Join unexpected error %v", err)
+	}
+	var keys []string
+	var errs []error
+	for j, err := range joined {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		keys = append(keys, j.Key)
+	}
+	if diff := cmp.Diff([]string{"1", "3"}, keys); diff != "" {
+		t.Errorf("Join found diff (-want +got):\n%s", diff)
+	}
+	if len(errs) != 1 {
+		t.Errorf("Join returned errors %v, want 1 error", errs)
+	}
+
+	// unsorted input ends a merge join
+	joined, err = Join(joinRows(t, left), joinRows(t, right), joinRowKey, joinRowKey, LeftJoin, MergeJoin)
+	if err != nil {
+		t.Fatalf("Join unexpected error %v", err)
+	}
+	var lastErr error
+	for _, err := range joined {
+		lastErr = err
+	}
+	var ie *IterationError
+	if !errors.Is(lastErr, ErrUnsortedInput) || !errors.As(lastErr, &ie) || ie.LineNumber() != 3 {
+		t.Errorf("Join error = %v, want IterationError in line 3 wrapping ErrUnsortedInput", lastErr)
+	}
+
+	// invalid arguments
+	rows := joinRows(t, left)
+	if _, err := Join(rows, nil, joinRowKey, joinRowKey, InnerJoin, HashJoin); err == nil {
+		t.Error("Join expected error for nil right iterator")
+	}
+	if _, err := Join(rows, rows, nil, joinRowKey, InnerJoin, HashJoin); err == nil {
+		t.Error("Join expected error for nil leftKey")
+	}
+	if _, err := Join(rows, rows, joinRowKey, joinRowKey, JoinType(5), HashJoin); err == nil {
+		t.Error("Join expected error for invalid join type")
+	}
+	if _, err := Join(rows, rows, joinRowKey, joinRowKey, InnerJoin, JoinStrategy(5)); err == nil {
+		t.Error("Join expected error for invalid strategy")
+	}
+}
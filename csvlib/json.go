@@ -0,0 +1,409 @@
+package csvlib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// JSONFormat is the structure of JSON data converted to or from CSV.
+type JSONFormat int
+
+const (
+	// JSONLines is a JSON object per line.
+	JSONLines JSONFormat = iota
+	// JSONArray is an array of JSON objects.
+	JSONArray
+)
+
+// String returns the JSONFormat name.
+func (f JSONFormat) String() string {
+	switch f {
+	case JSONLines:
+		return "JSON Lines"
+	case JSONArray:
+		return "JSON array"
+	}
+	return fmt.Sprintf("JSONFormat(%d)", int(f))
+}
+
+// jsonColumn is a CSV column converted to or from a JSON object member.
+type jsonColumn struct {
+	name string
+	// key is the column name encoded as a JSON string.
+	key []byte
+	// column is the schema Column, if the column is declared in the schema.
+	column *Column
+}
+
+// bindJSONColumns returns the jsonColumns for header, with each column's schema Column if schema is not nil.
+func bindJSONColumns(header []string, schema *Schema) ([]jsonColumn, error) {
+	if schema != nil {
+		if _, err := schema.bind(header); err != nil {
+			return nil, err
+		}
+	}
+
+	columns := make([]jsonColumn, len(header))
+	for i, name := range header {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = jsonColumn{name: name, key: key}
+		if schema != nil {
+			if j := slices.IndexFunc(schema.Columns, func(c Column) bool { return c.Name == name }); j >= 0 {
+				c := schema.Columns[j]
+				if c.Layout == "" {
+					c.Layout = time.RFC3339
+				}
+				columns[i].column = &c
+			}
+		}
+	}
+	return columns, nil
+}
+
+// appendJSONString appends s to dst as a JSON string, without escaping HTML characters.
+func appendJSONString(dst []byte, s string) []byte {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return append(dst, bytes.TrimSuffix(buf.Bytes(), []byte("\n"))...)
+}
+
+// appendJSONValue appends a CSV value to dst as a JSON value. Values are strings unless c is a schema Column, in which
+// case empty Nullable values are null and IntType, FloatType and BoolType values are numbers and booleans.
+func appendJSONValue(dst []byte, value string, c *Column) ([]byte, error) {
+	if c == nil {
+		return appendJSONString(dst, value), nil
+	}
+	if value == "" && c.Nullable {
+		return append(dst, "null"...), nil
+	}
+
+	switch c.Type {
+	case IntType:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return dst, err
+		}
+		return strconv.AppendInt(dst, i, 10), nil
+	case FloatType:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return dst, err
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return dst, fmt.Errorf("value %q is not a finite number", value)
+		}
+		return strconv.AppendFloat(dst, f, 'g', -1, 64), nil
+	case BoolType:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return dst, err
+		}
+		return strconv.AppendBool(dst, b), nil
+	case TimeType:
+		if _, err := time.Parse(c.Layout, value); err != nil {
+			return dst, err
+		}
+	}
+	return appendJSONString(dst, value), nil
+}
+
+// ConvertToJSON converts the records in input to JSON objects keyed by header names, written to output in format.
+// Values are JSON strings unless schema is not nil, in which case values of the schema's columns are coerced to JSON
+// numbers, booleans and nulls. See appendJSONValue for the coercion rules. A value which cannot be coerced is returned
+// as a ParseError for its column.
+//
+// Records are converted as they are read. The first error from the iterator stops the conversion and is returned,
+// unless excluded with an ErrorPolicy such as SkipErrors.
+func ConvertToJSON(input io.Reader, output io.Writer, format JSONFormat, schema *Schema, opts ...ReaderOption) error {
+	if format != JSONLines && format != JSONArray {
+		return fmt.Errorf("ConvertToJSON: invalid format %s", format)
+	}
+	if schema != nil {
+		if err := schema.validate(); err != nil {
+			return fmt.Errorf("ConvertToJSON: %w", err)
+		}
+	}
+
+	var columns []jsonColumn
+	headerFunc := func(header []string) error {
+		var err error
+		columns, err = bindJSONColumns(header, schema)
+		return err
+	}
+	parseFunc := func(fields []string) ([]byte, error) {
+		object := []byte{'{'}
+		for i, c := range columns {
+			if i > 0 {
+				object = append(object, ',')
+			}
+			object = append(append(object, c.key...), ':')
+
+			var value string
+			if i < len(fields) {
+				value = fields[i]
+			}
+			var err error
+			if object, err = appendJSONValue(object, value, c.column); err != nil {
+				return nil, NewColumnParseError(0, c.name, err)
+			}
+		}
+		return append(object, '}'), nil
+	}
+
+	records, err := iterator(input, true, DefaultBufferSize, headerFunc, parseFunc, opts)
+	if err != nil {
+		return fmt.Errorf("ConvertToJSON: %w", err)
+	}
+
+	buffer := bufio.NewWriterSize(output, DefaultBufferSize)
+	count := 0
+	for rec, err := range records {
+		if err != nil {
+			return fmt.Errorf("ConvertToJSON: %w", err)
+		}
+		switch {
+		case format == JSONLines:
+		case count == 0:
+			buffer.WriteString("[\n")
+		default:
+			buffer.WriteString(",\n")
+		}
+		buffer.Write(rec.Data)
+		if format == JSONLines {
+			buffer.WriteByte('\n')
+		}
+		count++
+	}
+
+	if format == JSONArray {
+		if count == 0 {
+			buffer.WriteString("[]\n")
+		} else {
+			buffer.WriteString("\n]\n")
+		}
+	}
+	if err := buffer.Flush(); err != nil {
+		return fmt.Errorf("ConvertToJSON: %w", err)
+	}
+	return nil
+}
+
+// ConvertFromJSON converts the JSON objects in input, in format, to CSV records written to output following the
+// header. Each object member is written to the column with the member's name. Missing and null members are written
+// as empty values, and members without a column are ignored. Strings are written as is, numbers and booleans as their
+// JSON text, and nested objects and arrays as compact JSON.
+//
+// header is derived from the schema's columns if header is nil, or otherwise from the members of the first object.
+// If schema is not nil, values of IntType columns must be integers, and are written without a fraction or exponent,
+// and values of other schema columns must be valid for their type. Members of schema columns which are neither
+// Nullable nor StringType are required, and may not be null.
+//
+// Objects are converted as they are decoded. The first invalid object stops the conversion and is returned.
+func ConvertFromJSON(input io.Reader,
+	output io.Writer,
+	format JSONFormat,
+	header []string,
+	schema *Schema,
+	opts ...WriterOption) error {
+
+	if format != JSONLines && format != JSONArray {
+		return fmt.Errorf("ConvertFromJSON: invalid format %s", format)
+	}
+	if schema != nil {
+		if err := schema.validate(); err != nil {
+			return fmt.Errorf("ConvertFromJSON: %w", err)
+		}
+		if header == nil {
+			for _, c := range schema.Columns {
+				header = append(header, c.Name)
+			}
+		}
+	}
+
+	writer, err := NewWriter(output, func(fields []string) ([]string, error) {
+		return fields, nil
+	}, opts...)
+	if err != nil {
+		return fmt.Errorf("ConvertFromJSON: %w", err)
+	}
+
+	decoder := json.NewDecoder(input)
+	decoder.UseNumber()
+	if format == JSONArray {
+		if err := expectDelim(decoder, '['); err != nil {
+			return fmt.Errorf("ConvertFromJSON: %w", err)
+		}
+	}
+
+	var columns []jsonColumn
+	var index map[string]int
+	for objectNumber := 1; ; objectNumber++ {
+		if format == JSONArray && !decoder.More() {
+			break
+		}
+		names, values, err := decodeObject(decoder)
+		if err == io.EOF && format == JSONLines {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("ConvertFromJSON: object %d: %w", objectNumber, err)
+		}
+
+		if columns == nil {
+			if header == nil {
+				header = names
+			}
+			if columns, err = bindJSONColumns(header, schema); err != nil {
+				return fmt.Errorf("ConvertFromJSON: %w", err)
+			}
+			index = make(map[string]int, len(header))
+			for i, name := range header {
+				index[name] = i
+			}
+			if err := writer.WriteHeader(header); err != nil {
+				return fmt.Errorf("ConvertFromJSON: %w", err)
+			}
+		}
+
+		fields := make([]string, len(columns))
+		// present records the members with a value other than null
+		present := make([]bool, len(columns))
+		for i, name := range names {
+			j, ok := index[name]
+			if !ok {
+				continue
+			}
+			if fields[j], err = csvValue(values[i], columns[j].column); err != nil {
+				return fmt.Errorf("ConvertFromJSON: object %d: member %q: %w", objectNumber, name, err)
+			}
+			present[j] = string(values[i]) != "null"
+		}
+		for j, c := range columns {
+			if c.column != nil && !present[j] && !c.column.Nullable && c.column.Type != StringType {
+				return fmt.Errorf("ConvertFromJSON: object %d: member %q is required", objectNumber, c.name)
+			}
+		}
+		if err := writer.Write(fields); err != nil {
+			return fmt.Errorf("ConvertFromJSON: %w", err)
+		}
+	}
+
+	if format == JSONArray {
+		if err := expectDelim(decoder, ']'); err != nil {
+			return fmt.Errorf("ConvertFromJSON: %w", err)
+		}
+	}
+	if columns == nil && header != nil {
+		if err := writer.WriteHeader(header); err != nil {
+			return fmt.Errorf("ConvertFromJSON: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("ConvertFromJSON: %w", err)
+	}
+	return nil
+}
+
+// expectDelim reads the next token from decoder, returning an error if it is not delim.
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %q, found %v", delim, token)
+	}
+	return nil
+}
+
+// decodeObject decodes the next JSON object from decoder, returning its member names in document order and their
+// raw values. io.EOF is returned if the input is exhausted.
+func decodeObject(decoder *json.Decoder) ([]string, []json.RawMessage, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, nil, fmt.Errorf("expected an object, found %v", token)
+	}
+
+	var names []string
+	var values []json.RawMessage
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		names = append(names, token.(string))
+		values = append(values, value)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	}
+	return names, values, nil
+}
+
+// csvValue returns a JSON value as a CSV value, checked against c if c is a schema Column.
+func csvValue(raw json.RawMessage, c *Column) (string, error) {
+	var value string
+	switch raw[0] {
+	case '"':
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return "", err
+		}
+	case 'n':
+		return "", nil
+	case '{', '[':
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, raw); err != nil {
+			return "", err
+		}
+		value = compacted.String()
+	default:
+		value = string(raw)
+	}
+	if c == nil || value == "" {
+		return value, nil
+	}
+
+	var err error
+	switch c.Type {
+	case IntType:
+		// integers are parsed exactly, other numbers such as 1.0 and 2e3 are accepted if they are exact integers
+		if _, err = strconv.ParseInt(value, 10, 64); err != nil {
+			var f float64
+			if f, err = strconv.ParseFloat(value, 64); err == nil {
+				if f != math.Trunc(f) || math.Abs(f) > 1<<53 {
+					return "", fmt.Errorf("value %s is not an integer", value)
+				}
+				value = strconv.FormatInt(int64(f), 10)
+			}
+		}
+	case FloatType:
+		_, err = strconv.ParseFloat(value, 64)
+	case BoolType:
+		_, err = strconv.ParseBool(value)
+	case TimeType:
+		_, err = time.Parse(c.Layout, value)
+	}
+	if err != nil {
+		return "", fmt.Errorf("value %s is not a valid %s", value, c.Type)
+	}
+	return value, nil
+}
@@ -0,0 +1,203 @@
+package csvlib
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConvertToJSON(t *testing.T) {
+	csvData := "id,name,score,active,note\n" +
+		"1,<ann>,1.50,true,\n" +
+		"2,\"bob \"\"b\"\"\",,false,x\n"
+	schema := &Schema{Columns: []Column{
+		{Name: "id", Type: IntType},
+		{Name: "score", Type: FloatType, Nullable: true},
+		{Name: "active", Type: BoolType},
+	}}
+
+	tests := []struct {
+		name   string
+		format JSONFormat
+		schema *Schema
+		want   string
+	}{
+		{
+			name:   "lines without schema",
+			format: JSONLines,
+			want: `{"id":"1","name":"<ann>","score":"1.50","active":"true","note":""}` + "\n" +
+				`{"id":"2","name":"bob \"b\"","score":"","active":"false","note":"x"}` + "\n",
+		},
+		{
+			name:   "lines with schema",
+			format: JSONLines,
+			schema: schema,
+			want: `{"id":1,"name":"<ann>","score":1.5,"active":true,"note":""}` + "\n" +
+				`{"id":2,"name":"bob \"b\"","score":null,"active":false,"note":"x"}` + "\n",
+		},
+		{
+			name:   "array with schema",
+			format: JSONArray,
+			schema: schema,
+			want: "[\n" +
+				`{"id":1,"name":"<ann>","score":1.5,"active":true,"note":""}` + ",\n" +
+				`{"id":2,"name":"bob \"b\"","score":null,"active":false,"note":"x"}` + "\n]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := ConvertToJSON(strings.NewReader(csvData), &output, tt.format, tt.schema); err != nil {
+				t.Fatalf("ConvertToJSON unexpected error %v", err)
+			}
+			if diff := cmp.Diff(tt.want, output.String()); diff != "" {
+				t.Errorf("ConvertToJSON found diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertToJSON_EmptyArray(t *testing.T) {
+	var output bytes.Buffer
+	if err := ConvertToJSON(strings.NewReader("id\n"), &output, JSONArray, nil); err != nil {
+		t.Fatalf("ConvertToJSON unexpected error %v", err)
+	}
+	if got := output.String(); got != "[]\n" {
+		t.Errorf("ConvertToJSON = %q, want %q", got, "[]\n")
+	}
+}
+
+func TestConvertToJSON_Errors(t *testing.T) {
+	schema := &Schema{Columns: []Column{{Name: "id", Type: IntType}}}
+
+	var output bytes.Buffer
+	err := ConvertToJSON(strings.NewReader("id\n1\nx\n"), &output, JSONLines, schema)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.LineNumber() != 3 || pe.Column() != "id" {
+		t.Errorf("ConvertToJSON error = %v, want a ParseError for column id on line 3", err)
+	}
+
+	output.Reset()
+	err = ConvertToJSON(strings.NewReader("id\n1\nx\n2\n"), &output, JSONLines, schema,
+		WithErrorPolicy(SkipErrors()))
+	if err != nil {
+		t.Fatalf("ConvertToJSON unexpected error %v", err)
+	}
+	if got, want := output.String(), "{\"id\":1}\n{\"id\":2}\n"; got != want {
+		t.Errorf("ConvertToJSON = %q, want %q", got, want)
+	}
+
+	required := &Schema{Columns: []Column{{Name: "missing", Required: true}}}
+	err = ConvertToJSON(strings.NewReader("id\n1\n"), &output, JSONLines, required)
+	if !errors.Is(err, ErrMissingColumn) {
+		t.Errorf("ConvertToJSON error = %v, want ErrMissingColumn", err)
+	}
+
+	if err := ConvertToJSON(strings.NewReader("id\n"), &output, JSONFormat(5), nil); err == nil {
+		t.Error("ConvertToJSON expected an error for an invalid format")
+	}
+}
+
+func TestConvertFromJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format JSONFormat
+		header []string
+		schema *Schema
+		want   string
+	}{
+		{
+			name: "lines with derived header",
+			input: `{"id":1,"name":"ann, a","tags":["x", "y"],"active":true}` + "\n" +
+				`{"name":"bob","id":2,"active":null,"extra":{"a": 1}}` + "\n",
+			format: JSONLines,
+			want:   "id,name,tags,active\n1,\"ann, a\",\"[\"\"x\"\",\"\"y\"\"]\",true\n2,bob,,\n",
+		},
+		{
+			name:   "array with header",
+			input:  `[{"id":1,"name":"ann"}, {"name":"bob","id":2}]`,
+			format: JSONArray,
+			header: []string{"name", "id"},
+			want:   "name,id\nann,1\nbob,2\n",
+		},
+		{
+			name:   "array with schema",
+			input:  `[{"id":1.0,"score":2.5}, {"id":2e1,"score":null}, {"id":9007199254740993,"score":1}]`,
+			format: JSONArray,
+			schema: &Schema{Columns: []Column{
+				{Name: "id", Type: IntType},
+				{Name: "score", Type: FloatType, Nullable: true},
+			}},
+			want: "id,score\n1,2.5\n20,\n9007199254740993,1\n",
+		},
+		{
+			name:   "empty array with header",
+			input:  "[]",
+			format: JSONArray,
+			header: []string{"id"},
+			want:   "id\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := ConvertFromJSON(strings.NewReader(tt.input), &output, tt.format, tt.header, tt.schema)
+			if err != nil {
+				t.Fatalf("ConvertFromJSON unexpected error %v", err)
+			}
+			if diff := cmp.Diff(tt.want, output.String()); diff != "" {
+				t.Errorf("ConvertFromJSON found diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertFromJSON_Errors(t *testing.T) {
+	schema := &Schema{Columns: []Column{{Name: "id", Type: IntType}}}
+
+	tests := []struct {
+		name   string
+		input  string
+		format JSONFormat
+		schema *Schema
+	}{
+		{name: "not an object", input: `{"id":1}` + "\n[1]\n", format: JSONLines},
+		{name: "malformed", input: `{"id":1`, format: JSONLines},
+		{name: "not an array", input: `{"id":1}`, format: JSONArray},
+		{name: "unterminated array", input: `[{"id":1}`, format: JSONArray},
+		{name: "not an integer", input: `{"id":1.5}`, format: JSONLines, schema: schema},
+		{name: "required", input: `{"other":1}`, format: JSONLines, schema: schema},
+		{name: "required null", input: `{"id":null}`, format: JSONLines, schema: schema},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := ConvertFromJSON(strings.NewReader(tt.input), &output, tt.format, nil, tt.schema); err == nil {
+				t.Error("ConvertFromJSON expected an error")
+			}
+		})
+	}
+}
+
+func TestConvertJSON_RoundTrip(t *testing.T) {
+	csvData := "id,name,note\n1,\"ann\nsmith\",\"a,b\"\n2,bob,\n"
+
+	var jsonData bytes.Buffer
+	if err := ConvertToJSON(strings.NewReader(csvData), &jsonData, JSONLines, nil); err != nil {
+		t.Fatalf("ConvertToJSON unexpected error %v", err)
+	}
+	var output bytes.Buffer
+	if err := ConvertFromJSON(&jsonData, &output, JSONLines, nil, nil); err != nil {
+		t.Fatalf("ConvertFromJSON unexpected error %v", err)
+	}
+	if diff := cmp.Diff(csvData, output.String()); diff != "" {
+		t.Errorf("round trip found diff (-want +got):\n%s", diff)
+	}
+}
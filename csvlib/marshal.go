@@ -0,0 +1,158 @@
+package csvlib
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// textMarshalerType is the reflect.Type of encoding.TextMarshaler.
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// encodeFunc encodes an addressable reflect.Value as a CSV field value.
+type encodeFunc func(src reflect.Value) (string, error)
+
+// fieldEncoder pairs a tagged struct field with its encodeFunc.
+type fieldEncoder struct {
+	structField
+	encode encodeFunc
+}
+
+// NewStructConvertFunc returns a ConvertFunc which converts T to a CSV record using its csv struct tags, along with the
+// header for the record.
+//
+// columns selects and orders the output columns by tag name. All tagged fields are written in declaration order if
+// columns is empty.
+//
+// T must be a struct type. Supported field types are string, bool, signed and unsigned integers, floats, time.Time
+// (formatted with the field's layout tag, time.RFC3339 by default), types implementing encoding.TextMarshaler, and
+// pointers to any of these. Nil pointer fields are written as empty values.
+func NewStructConvertFunc[T any](columns []string) (ConvertFunc[T], []string, error) {
+	encoders, err := structEncoders(reflect.TypeFor[T](), columns)
+	if err != nil {
+		return nil, nil, fmt.Errorf("NewStructConvertFunc: %w", err)
+	}
+
+	header := make([]string, len(encoders))
+	for i, e := range encoders {
+		header[i] = e.column
+	}
+
+	convertFunc := func(data T) ([]string, error) {
+		source := reflect.ValueOf(&data).Elem()
+		csvFields := make([]string, len(encoders))
+		for i, e := range encoders {
+			value, err := e.encode(source.FieldByIndex(e.index))
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", e.column, err)
+			}
+			csvFields[i] = value
+		}
+		return csvFields, nil
+	}
+	return convertFunc, header, nil
+}
+
+// NewStructWriter creates a new Writer which writes records of type T using its csv struct tags.
+// The header derived from the struct tags is available from Writer.Header.
+// See NewStructConvertFunc for column selection and supported field types.
+func NewStructWriter[T any](output io.Writer, columns []string, opts ...WriterOption) (Writer[T], error) {
+	convertFunc, header, err := NewStructConvertFunc[T](columns)
+	if err != nil {
+		return Writer[T]{}, fmt.Errorf("NewStructWriter: %w", err)
+	}
+
+	w, err := NewWriter(output, convertFunc, opts...)
+	if err != nil {
+		return Writer[T]{}, err
+	}
+	w.header = header
+	return w, nil
+}
+
+// structEncoders returns the fieldEncoders for struct type t, selected and ordered by columns.
+func structEncoders(t reflect.Type, columns []string) ([]fieldEncoder, error) {
+	fields, err := structFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) > 0 {
+		byColumn := make(map[string]structField, len(fields))
+		for _, f := range fields {
+			byColumn[f.column] = f
+		}
+		selected := make([]structField, 0, len(columns))
+		for _, column := range columns {
+			f, ok := byColumn[column]
+			if !ok {
+				return nil, fmt.Errorf("%w %q in %s", ErrMissingColumn, column, t)
+			}
+			selected = append(selected, f)
+		}
+		fields = selected
+	}
+
+	encoders := make([]fieldEncoder, 0, len(fields))
+	for _, f := range fields {
+		encode, err := newEncodeFunc(f.typ, f.layout)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", f.column, err)
+		}
+		encoders = append(encoders, fieldEncoder{structField: f, encode: encode})
+	}
+	return encoders, nil
+}
+
+// newEncodeFunc returns the encodeFunc for type t.
+func newEncodeFunc(t reflect.Type, layout string) (encodeFunc, error) {
+	switch {
+	case t == timeType:
+		return func(src reflect.Value) (string, error) {
+			return src.Interface().(time.Time).Format(layout), nil
+		}, nil
+	case t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(textMarshalerType):
+		return func(src reflect.Value) (string, error) {
+			text, err := src.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+			return string(text), err
+		}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elemEncode, err := newEncodeFunc(t.Elem(), layout)
+		if err != nil {
+			return nil, err
+		}
+		return func(src reflect.Value) (string, error) {
+			if src.IsNil() {
+				return "", nil
+			}
+			return elemEncode(src.Elem())
+		}, nil
+	case reflect.String:
+		return func(src reflect.Value) (string, error) {
+			return src.String(), nil
+		}, nil
+	case reflect.Bool:
+		return func(src reflect.Value) (string, error) {
+			return strconv.FormatBool(src.Bool()), nil
+		}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(src reflect.Value) (string, error) {
+			return strconv.FormatInt(src.Int(), 10), nil
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(src reflect.Value) (string, error) {
+			return strconv.FormatUint(src.Uint(), 10), nil
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(src reflect.Value) (string, error) {
+			return strconv.FormatFloat(src.Float(), 'f', -1, t.Bits()), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported field type %s", t)
+}
@@ -0,0 +1,80 @@
+package csvlib
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStructWriter(t *testing.T) {
+	nickname := "Johnny"
+	records := []TaggedRecord{
+		{
+			TaggedBase: TaggedBase{ID: 1},
+			Name:       "John",
+			Age:        42,
+			Score:      9.5,
+			Active:     true,
+			Born:       time.Date(1980, 2, 3, 0, 0, 0, 0, time.UTC),
+			Address:    netip.MustParseAddr("10.0.0.1"),
+			Nickname:   &nickname,
+		},
+		{
+			TaggedBase: TaggedBase{ID: 2},
+			Name:       "Jane",
+			Born:       time.Date(1985, 6, 7, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	tests := map[string]struct {
+		columns []string
+		want    string
+	}{
+		"all-columns": {
+			want: "id,name,age,score,active,born,address,nickname,missing\n" +
+				"1,John,42,9.5,true,1980-02-03,10.0.0.1,Johnny,\n" +
+				"2,Jane,0,0,false,1985-06-07,,,\n",
+		},
+		"selected-columns": {
+			columns: []string{"born", "name"},
+			want: "born,name\n" +
+				"1980-02-03,John\n" +
+				"1985-06-07,Jane\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output bytes.Buffer
+			w, err := NewStructWriter[TaggedRecord](&output, tt.columns)
+			if err != nil {
+				t.Fatalf("NewStructWriter unexpected error %v", err)
+			}
+
+			if err := w.WriteHeader(w.Header()); err != nil {
+				t.Fatalf("WriteHeader unexpected error %v", err)
+			}
+			for _, rec := range records {
+				if err := w.Write(rec); err != nil {
+					t.Fatalf("Write unexpected error %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close unexpected error %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, output.String()); diff != "" {
+				t.Errorf("Writer did not write expected contents (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewStructConvertFunc_UnknownColumn(t *testing.T) {
+	if _, _, err := NewStructConvertFunc[TaggedRecord]([]string{"email"}); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+}
@@ -0,0 +1,247 @@
+package csvlib
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maskKind identifies the transformation applied by a Mask.
+type maskKind int
+
+const (
+	maskHash maskKind = iota + 1
+	maskPartial
+	maskDrop
+	maskToken
+)
+
+// Mask determines how the values of a masked column are transformed.
+// Empty values are not transformed by any Mask other than DropMask.
+type Mask struct {
+	kind maskKind
+	// salt keys the HMAC of HashMask values.
+	salt []byte
+	// keepFirst and keepLast are the number of leading and trailing runes left unmasked by PartialMask.
+	keepFirst int
+	keepLast  int
+	maskChar  rune
+	tokenizer *Tokenizer
+}
+
+// HashMask returns a Mask which replaces values with the hex encoded HMAC-SHA256 of the value keyed by salt.
+// salt is required, as unsalted hashes of values such as phone numbers are easily reversed.
+// Equal values have equal hashes for the same salt, so hashed columns may still be joined and counted.
+func HashMask(salt []byte) Mask {
+	return Mask{kind: maskHash, salt: salt}
+}
+
+// PartialMask returns a Mask which replaces each rune of a value with maskChar, other than the first keepFirst and
+// last keepLast runes. For example, PartialMask(0, 4, '*') masks "123-45-6789" as "*******6789". Values no longer than
+// keepFirst + keepLast runes are masked entirely.
+func PartialMask(keepFirst, keepLast int, maskChar rune) Mask {
+	return Mask{kind: maskPartial, keepFirst: keepFirst, keepLast: keepLast, maskChar: maskChar}
+}
+
+// DropMask returns a Mask which removes the column from the header and from each record.
+func DropMask() Mask {
+	return Mask{kind: maskDrop}
+}
+
+// TokenMask returns a Mask which replaces values with tokens issued by tokenizer.
+func TokenMask(tokenizer *Tokenizer) Mask {
+	return Mask{kind: maskToken, tokenizer: tokenizer}
+}
+
+// apply returns the masked value.
+func (m *Mask) apply(value string) string {
+	if value == "" {
+		return value
+	}
+	switch m.kind {
+	case maskHash:
+		mac := hmac.New(sha256.New, m.salt)
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))
+	case maskPartial:
+		runes := []rune(value)
+		if len(runes) <= m.keepFirst+m.keepLast {
+			return strings.Repeat(string(m.maskChar), len(runes))
+		}
+		for i := m.keepFirst; i < len(runes)-m.keepLast; i++ {
+			runes[i] = m.maskChar
+		}
+		return string(runes)
+	case maskToken:
+		return m.tokenizer.Token(value)
+	}
+	return value
+}
+
+// Tokenizer replaces values with tokens, issuing the same token for each occurrence of a value.
+// Tokens are the Tokenizer's prefix followed by a sequence number, in the order values are first seen. A Tokenizer may
+// be shared by multiple columns, iterators and Writers, so that a value is tokenized consistently across files, and is
+// safe for concurrent use.
+type Tokenizer struct {
+	mu     sync.Mutex
+	prefix string
+	tokens map[string]string
+}
+
+// NewTokenizer returns a Tokenizer which issues tokens beginning with prefix.
+func NewTokenizer(prefix string) *Tokenizer {
+	return &Tokenizer{prefix: prefix, tokens: make(map[string]string)}
+}
+
+// Token returns the token for value, issuing a new token if value has not been seen.
+func (t *Tokenizer) Token(value string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	token, ok := t.tokens[value]
+	if !ok {
+		token = t.prefix + strconv.Itoa(len(t.tokens)+1)
+		t.tokens[value] = token
+	}
+	return token
+}
+
+// Tokens returns a copy of the mapping from values to issued tokens, which may be retained securely to reverse the
+// tokenization.
+func (t *Tokenizer) Tokens() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tokens := make(map[string]string, len(t.tokens))
+	for value, token := range t.tokens {
+		tokens[value] = token
+	}
+	return tokens
+}
+
+// MaskRule applies a Mask to a column.
+type MaskRule struct {
+	// Column is the header column name. If the input or output does not have a header, Column is the 1-based field
+	// position, e.g. "3".
+	Column string
+	Mask   Mask
+}
+
+// WithMasking masks column values. Iterators mask each record as it is read, before it is validated and passed to the
+// ParseFunc, so that masked values do not appear in Records, errors or rejects. Records with the wrong number of fields
+// are masked by field position. Writers mask each record after it is converted by the
+// ConvertFunc. Dropped columns are also removed from the header passed to the ParseFunc's header handling, such as the
+// struct iterator's field binding, and from the header written by WriteHeader.
+//
+// Every rule's column must be present: an iterator returns an IterationError wrapping ErrMissingColumn, and a Writer
+// returns an error from WriteHeader, or from the first Write if a header is not written.
+func WithMasking(rules ...MaskRule) Option {
+	return option{
+		reader: func(c *readerConfig) { c.masking = rules },
+		writer: func(c *writerConfig) { c.masking = rules },
+	}
+}
+
+// validateMasking returns an error if the masking rules are not well-formed.
+func validateMasking(rules []MaskRule) error {
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if r.Column == "" {
+			return errors.New("mask column name is required")
+		}
+		if seen[r.Column] {
+			return fmt.Errorf("column %q has multiple masks", r.Column)
+		}
+		seen[r.Column] = true
+
+		m := r.Mask
+		switch {
+		case m.kind < maskHash || m.kind > maskToken:
+			return fmt.Errorf("column %q has an invalid mask", r.Column)
+		case m.kind == maskPartial && (m.keepFirst < 0 || m.keepLast < 0 || !utf8.ValidRune(m.maskChar)):
+			return fmt.Errorf("column %q has an invalid partial mask", r.Column)
+		case m.kind == maskHash && len(m.salt) == 0:
+			return fmt.Errorf("column %q has a hash mask without a salt", r.Column)
+		case m.kind == maskToken && m.tokenizer == nil:
+			return fmt.Errorf("column %q has a token mask without a tokenizer", r.Column)
+		}
+	}
+	return nil
+}
+
+// fieldMasker applies masking rules bound to field positions.
+type fieldMasker struct {
+	// masks contains the Mask for each field position, or nil if the field is not masked.
+	masks []*Mask
+	drops bool
+}
+
+// bindMasks returns a fieldMasker for the header. Rules are bound by 1-based position if header is nil.
+func bindMasks(rules []MaskRule, header []string) (*fieldMasker, error) {
+	var h *Header
+	if header != nil {
+		h = NewHeader(header)
+		columns := make([]string, len(rules))
+		for i, r := range rules {
+			columns[i] = r.Column
+		}
+		if err := h.Require(columns...); err != nil {
+			return nil, err
+		}
+	}
+
+	m := &fieldMasker{masks: make([]*Mask, len(header))}
+	for _, r := range rules {
+		var index int
+		if h != nil {
+			index, _ = h.Index(r.Column)
+		} else {
+			position, err := strconv.Atoi(r.Column)
+			if err != nil || position < 1 {
+				return nil, fmt.Errorf("mask column %q is not a field position", r.Column)
+			}
+			index = position - 1
+		}
+		if index >= len(m.masks) {
+			m.masks = append(m.masks, make([]*Mask, index+1-len(m.masks))...)
+		}
+		m.masks[index] = &r.Mask
+		m.drops = m.drops || r.Mask.kind == maskDrop
+	}
+	return m, nil
+}
+
+// header returns the header without dropped columns.
+func (m *fieldMasker) header(header []string) []string {
+	if !m.drops {
+		return header
+	}
+	masked := make([]string, 0, len(header))
+	for i, name := range header {
+		if i >= len(m.masks) || m.masks[i] == nil || m.masks[i].kind != maskDrop {
+			masked = append(masked, name)
+		}
+	}
+	return masked
+}
+
+// apply returns the masked fields. fields is not modified.
+func (m *fieldMasker) apply(fields []string) []string {
+	masked := make([]string, 0, len(fields))
+	for i, field := range fields {
+		if i >= len(m.masks) || m.masks[i] == nil {
+			masked = append(masked, field)
+			continue
+		}
+		if m.masks[i].kind != maskDrop {
+			masked = append(masked, m.masks[i].apply(field))
+		}
+	}
+	return masked
+}
@@ -0,0 +1,245 @@
+package csvlib
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// hashValue returns the HashMask value for value keyed by salt.
+func hashValue(salt, value string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWithMasking_Iterator(t *testing.T) {
+	csvData := "name,ssn,email,phone\n" +
+		"ann,123-45-6789,ann@example.com,555-0100\n" +
+		"bob,987-65-4321,,555-0101\n" +
+		"ann,12,ann@example.com,555-0102\n"
+	tokenizer := NewTokenizer("person-")
+	rules := []MaskRule{
+		{Column: "name", Mask: TokenMask(tokenizer)},
+		{Column: "ssn", Mask: PartialMask(0, 4, '*')},
+		{Column: "email", Mask: HashMask([]byte("salt"))},
+		{Column: "phone", Mask: DropMask()},
+	}
+
+	iterator, err := NewHeaderIterator(strings.NewReader(csvData), nil, func(row Row) ([]string, error) {
+		if _, ok := row.Lookup("phone"); ok {
+			return nil, errors.New("phone column was not dropped")
+		}
+		return row.Fields(), nil
+	}, WithMasking(rules...), WithRawFields())
+	if err != nil {
+		t.Fatalf("NewHeaderIterator unexpected error %v", err)
+	}
+
+	var got [][]string
+	for rec, err := range iterator {
+		if err != nil {
+			t.Fatalf("iterator unexpected error %v", err)
+		}
+		if !cmp.Equal(rec.Raw, rec.Data) {
+			t.Errorf("raw fields %q are not masked", rec.Raw)
+		}
+		got = append(got, rec.Data)
+	}
+
+	email := hashValue("salt", "ann@example.com")
+	want := [][]string{
+		{"person-1", "*******6789", email},
+		{"person-2", "*******4321", ""},
+		{"person-1", "**", email},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("masked records found diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"ann": "person-1", "bob": "person-2"}, tokenizer.Tokens()); diff != "" {
+		t.Errorf("Tokens found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithMasking_IteratorErrors(t *testing.T) {
+	parseFunc := func(fields []string) ([]string, error) { return fields, nil }
+
+	tests := []struct {
+		name      string
+		csvData   string
+		hasHeader bool
+		rules     []MaskRule
+		want      []string
+		wantErr   error
+	}{
+		{
+			name:      "missing column",
+			csvData:   "name\nann\n",
+			hasHeader: true,
+			rules:     []MaskRule{{Column: "ssn", Mask: DropMask()}},
+			wantErr:   ErrMissingColumn,
+		},
+		{
+			name:    "position",
+			csvData: "ann,123-45-6789\n",
+			rules:   []MaskRule{{Column: "2", Mask: PartialMask(3, 0, '#')}},
+			want:    []string{"ann", "123########"},
+		},
+		{
+			name:    "invalid position",
+			csvData: "ann,123-45-6789\n",
+			rules:   []MaskRule{{Column: "ssn", Mask: DropMask()}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iterator, err := NewDefaultIterator(strings.NewReader(tt.csvData), tt.hasHeader, parseFunc,
+				WithMasking(tt.rules...))
+			if err != nil {
+				t.Fatalf("NewDefaultIterator unexpected error %v", err)
+			}
+			for rec, err := range iterator {
+				if tt.want != nil {
+					if err != nil {
+						t.Fatalf("iterator unexpected error %v", err)
+					}
+					if diff := cmp.Diff(tt.want, rec.Data); diff != "" {
+						t.Errorf("masked record found diff (-want +got):\n%s", diff)
+					}
+					continue
+				}
+				var ie *IterationError
+				if !errors.As(err, &ie) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+					t.Errorf("iterator error = %v, want an IterationError wrapping %v", err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestWithMasking_Writer(t *testing.T) {
+	tokenizer := NewTokenizer("T")
+	rules := []MaskRule{
+		{Column: "last", Mask: TokenMask(tokenizer)},
+		{Column: "first", Mask: DropMask()},
+	}
+
+	var output bytes.Buffer
+	writer, err := NewWriter(&output, customRecordConvertFunc, WithMasking(rules...))
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+	if err := writer.WriteHeader([]string{"first", "last"}); err != nil {
+		t.Fatalf("WriteHeader unexpected error %v", err)
+	}
+	for _, rec := range []CustomRecord{{"ann", "smith"}, {"bob", "jones"}, {"cal", "smith"}} {
+		if err := writer.Write(rec); err != nil {
+			t.Fatalf("Write unexpected error %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+	if diff := cmp.Diff("last\nT1\nT2\nT1\n", output.String()); diff != "" {
+		t.Errorf("output found diff (-want +got):\n%s", diff)
+	}
+
+	// without a header, rules are bound by position
+	output.Reset()
+	writer, err = NewWriter(&output, customRecordConvertFunc,
+		WithMasking(MaskRule{Column: "1", Mask: PartialMask(1, 0, '*')}))
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+	writer.Write(CustomRecord{"ann", "smith"})
+	writer.Close()
+	if got := output.String(); got != "a**,smith\n" {
+		t.Errorf("output = %q, want %q", got, "a**,smith\n")
+	}
+
+	writer, err = NewWriter(&output, customRecordConvertFunc, WithMasking(rules...))
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+	if err := writer.WriteHeader([]string{"first"}); !errors.Is(err, ErrMissingColumn) {
+		t.Errorf("WriteHeader error = %v, want ErrMissingColumn", err)
+	}
+	if err := writer.Write(CustomRecord{"ann", "smith"}); err == nil {
+		t.Error("Write expected an error for masking rules without a header")
+	}
+}
+
+func TestWithMasking_InvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []MaskRule
+	}{
+		{name: "column", rules: []MaskRule{{Mask: DropMask()}}},
+		{name: "duplicate", rules: []MaskRule{{Column: "a", Mask: DropMask()}, {Column: "a", Mask: DropMask()}}},
+		{name: "zero mask", rules: []MaskRule{{Column: "a"}}},
+		{name: "partial", rules: []MaskRule{{Column: "a", Mask: PartialMask(-1, 0, '*')}}},
+		{name: "tokenizer", rules: []MaskRule{{Column: "a", Mask: TokenMask(nil)}}},
+		{name: "salt", rules: []MaskRule{{Column: "a", Mask: HashMask(nil)}}},
+		{name: "empty salt", rules: []MaskRule{{Column: "a", Mask: HashMask([]byte{})}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWriter(&bytes.Buffer{}, customRecordConvertFunc, WithMasking(tt.rules...)); err == nil {
+				t.Error("NewWriter expected an error")
+			}
+			parseFunc := func(fields []string) ([]string, error) { return fields, nil }
+			if _, err := NewDefaultIterator(strings.NewReader(""), true, parseFunc, WithMasking(tt.rules...)); err == nil {
+				t.Error("NewDefaultIterator expected an error")
+			}
+		})
+	}
+}
+
+func TestWithMasking_Rejects(t *testing.T) {
+	csvData := "name,ssn\nann,123-45-6789\nbob,987-65-4321,extra\ncal\n"
+
+	var rejectOutput bytes.Buffer
+	rejects, err := NewRejectWriter(&rejectOutput)
+	if err != nil {
+		t.Fatalf("NewRejectWriter unexpected error %v", err)
+	}
+	iterator, err := NewDefaultIterator(strings.NewReader(csvData), true,
+		func(fields []string) ([]string, error) { return fields, nil },
+		WithMasking(MaskRule{Column: "ssn", Mask: PartialMask(0, 4, '*')}), WithRawFields(), WithRejects(&rejects))
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+
+	var errs []error
+	for _, err := range iterator {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := rejects.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+
+	// the record with the wrong number of fields is masked by position
+	if len(errs) != 2 {
+		t.Fatalf("iterator yielded %d errors, want 2: %v", len(errs), errs)
+	}
+	var ie *IterationError
+	if !errors.As(errs[0], &ie) || !cmp.Equal(ie.Raw(), []string{"bob", "*******4321", "extra"}) {
+		t.Errorf("error raw fields = %v, want masked fields", errs[0])
+	}
+	if strings.Contains(rejectOutput.String(), "987-65-4321") {
+		t.Errorf("rejects contain the unmasked value:\n%s", rejectOutput.String())
+	}
+	if !strings.Contains(rejectOutput.String(), "bob,*******4321,extra") {
+		t.Errorf("rejects do not contain the masked record:\n%s", rejectOutput.String())
+	}
+}
@@ -0,0 +1,274 @@
+package csvlib
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// ReaderOption configures an iterator.
+type ReaderOption interface {
+	applyReader(*readerConfig)
+}
+
+// WriterOption configures a Writer.
+type WriterOption interface {
+	applyWriter(*writerConfig)
+}
+
+// Option configures both iterators and Writers.
+type Option interface {
+	ReaderOption
+	WriterOption
+}
+
+// option implements ReaderOption and WriterOption.
+// The exported option constructors return option as ReaderOption, WriterOption or Option to restrict where the option
+// may be used.
+type option struct {
+	reader func(*readerConfig)
+	writer func(*writerConfig)
+}
+
+func (o option) applyReader(c *readerConfig) {
+	if o.reader != nil {
+		o.reader(c)
+	}
+}
+
+func (o option) applyWriter(c *writerConfig) {
+	if o.writer != nil {
+		o.writer(c)
+	}
+}
+
+// readerConfig contains the iterator settings applied by ReaderOptions.
+type readerConfig struct {
+	bufferSize       int
+	comma            rune
+	comment          rune
+	lazyQuotes       bool
+	trimLeadingSpace bool
+	fieldsPerRecord  int
+	ctx              context.Context
+	errorPolicy      ErrorPolicy
+	rejects          *Writer[Reject]
+	rawFields        bool
+	checkpoint       *Checkpoint
+	checkpointEvery  int
+	checkpointFunc   func(Checkpoint)
+	schema           *Schema
+	// disableDecompression disables the detection of compressed input.
+	disableDecompression bool
+	// zlib enables the detection of zlib compressed input.
+	zlib bool
+	// source is the name of the input, for iterators which read multiple inputs.
+	source string
+	// charset is the character encoding of input without a byte order mark.
+	charset Charset
+	// layout, if not nil, reads fixed-width records rather than delimited records.
+	layout *FixedLayout
+	// detectFormulas returns records with cells which may be evaluated as formulas as ValidationErrors.
+	detectFormulas bool
+	// masking contains the rules masking column values before records are validated and parsed.
+	masking []MaskRule
+}
+
+// newReaderConfig returns a readerConfig with the specified buffer size and options applied.
+func newReaderConfig(bufferSize int, opts []ReaderOption) (readerConfig, error) {
+	cfg := readerConfig{bufferSize: bufferSize, comma: ',', ctx: context.Background()}
+	for _, opt := range opts {
+		opt.applyReader(&cfg)
+	}
+
+	if cfg.bufferSize < DefaultBufferSize {
+		cfg.bufferSize = DefaultBufferSize
+	}
+	if cfg.ctx == nil {
+		return cfg, errors.New("nil context")
+	}
+	if !validDelimiter(cfg.comma) {
+		return cfg, fmt.Errorf("invalid delimiter %q", cfg.comma)
+	}
+	if cfg.comment != 0 && (cfg.comment == cfg.comma || !validDelimiter(cfg.comment)) {
+		return cfg, fmt.Errorf("invalid comment character %q", cfg.comment)
+	}
+	if cfg.charset < UTF8 || cfg.charset > Windows1252 {
+		return cfg, fmt.Errorf("invalid charset %s", cfg.charset)
+	}
+	if cfg.schema != nil {
+		if err := cfg.schema.validate(); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.layout != nil {
+		if err := cfg.layout.validate(); err != nil {
+			return cfg, err
+		}
+	}
+	if err := validateMasking(cfg.masking); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// validateInput returns an error if input does not support the configured options.
+func (c *readerConfig) validateInput(input io.Reader) error {
+	if c.checkpoint != nil {
+		if _, ok := input.(io.Seeker); !ok {
+			return errors.New("resuming from a checkpoint requires an io.ReadSeeker")
+		}
+	}
+	return nil
+}
+
+// newDecoder returns a recordDecoder for input configured with the reader dialect.
+func (c *readerConfig) newDecoder(input io.Reader) recordDecoder {
+	if c.layout != nil {
+		return &fixedDecoder{reader: bufio.NewReaderSize(input, c.bufferSize), layout: c.layout, comment: c.comment}
+	}
+	reader := csv.NewReader(input)
+	reader.Comma = c.comma
+	reader.Comment = c.comment
+	reader.LazyQuotes = c.lazyQuotes
+	reader.TrimLeadingSpace = c.trimLeadingSpace
+	reader.FieldsPerRecord = c.fieldsPerRecord
+	return csvDecoder{reader: reader}
+}
+
+// writerConfig contains the Writer settings applied by WriterOptions.
+type writerConfig struct {
+	bufferSize int
+	comma      rune
+	useCRLF    bool
+	quoteAll   bool
+	gzip       bool
+	bom        bool
+	// layout, if not nil, writes fixed-width records rather than delimited records.
+	layout      *FixedLayout
+	flushPolicy FlushPolicy
+	// logEvent, if not empty, is the event source of the stats logged at Close.
+	logEvent string
+	logLevel slog.Level
+	// formulaEscaping, if not nil, neutralizes cells which may be evaluated as formulas.
+	formulaEscaping *FormulaEscaping
+	// masking contains the rules masking column values before records are written.
+	masking []MaskRule
+}
+
+// newWriterConfig returns a writerConfig with the options applied.
+func newWriterConfig(opts []WriterOption) (writerConfig, error) {
+	cfg := writerConfig{bufferSize: DefaultBufferSize, comma: ','}
+	for _, opt := range opts {
+		opt.applyWriter(&cfg)
+	}
+
+	if cfg.bufferSize < DefaultBufferSize {
+		cfg.bufferSize = DefaultBufferSize
+	}
+	if !validDelimiter(cfg.comma) {
+		return cfg, fmt.Errorf("invalid delimiter %q", cfg.comma)
+	}
+	if cfg.layout != nil {
+		if err := cfg.layout.validate(); err != nil {
+			return cfg, err
+		}
+	}
+	if e := cfg.formulaEscaping; e != nil && (*e < EscapeWithPrefix || *e > EscapeWithQuotes) {
+		return cfg, fmt.Errorf("invalid formula escaping %s", *e)
+	}
+	if err := validateMasking(cfg.masking); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// validDelimiter reports whether r may be used as a field delimiter or comment character.
+// The rules mirror those enforced by encoding/csv.
+func validDelimiter(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
+
+// WithBufferSize sets the read or write buffer size.
+// DefaultBufferSize is used if size < DefaultBufferSize.
+func WithBufferSize(size int) Option {
+	return option{
+		reader: func(c *readerConfig) { c.bufferSize = size },
+		writer: func(c *writerConfig) { c.bufferSize = size },
+	}
+}
+
+// WithDelimiter sets the field delimiter, e.g. '\t' for TSV or ';' for semicolon delimited files.
+// The default delimiter is ','.
+func WithDelimiter(delimiter rune) Option {
+	return option{
+		reader: func(c *readerConfig) { c.comma = delimiter },
+		writer: func(c *writerConfig) { c.comma = delimiter },
+	}
+}
+
+// WithComment skips lines beginning with the comment character, e.g. '#'.
+func WithComment(comment rune) ReaderOption {
+	return option{reader: func(c *readerConfig) { c.comment = comment }}
+}
+
+// WithLazyQuotes allows quotes to appear in unquoted fields and non-doubled quotes to appear in quoted fields.
+func WithLazyQuotes() ReaderOption {
+	return option{reader: func(c *readerConfig) { c.lazyQuotes = true }}
+}
+
+// WithTrimLeadingSpace ignores leading white space in fields.
+func WithTrimLeadingSpace() ReaderOption {
+	return option{reader: func(c *readerConfig) { c.trimLeadingSpace = true }}
+}
+
+// WithFieldsPerRecord sets the number of fields expected in each record.
+// If n > 0 each record must have n fields. If n == 0, the default, each record must have the same number of fields as
+// the first record. If n < 0 records may have a variable number of fields.
+func WithFieldsPerRecord(n int) ReaderOption {
+	return option{reader: func(c *readerConfig) { c.fieldsPerRecord = n }}
+}
+
+// WithContext stops iteration when ctx is done.
+// The context is checked before each record is read. Once the context is done, the iterator yields a final
+// IterationError wrapping ctx.Err() for the line number reached.
+func WithContext(ctx context.Context) ReaderOption {
+	return option{reader: func(c *readerConfig) { c.ctx = ctx }}
+}
+
+// WithRawFields includes the raw record fields in each Record and in record errors.
+func WithRawFields() ReaderOption {
+	return option{reader: func(c *readerConfig) { c.rawFields = true }}
+}
+
+// WithCheckpoints calls checkpointFunc after every n records have been processed by the consumer, and skipped or
+// rejected by the ErrorPolicy, with the Checkpoint from which iteration may resume.
+// checkpointFunc is called from the goroutine ranging over the iterator, once the range loop body for the nth record
+// has completed.
+func WithCheckpoints(n int, checkpointFunc func(Checkpoint)) ReaderOption {
+	return option{reader: func(c *readerConfig) {
+		c.checkpointEvery = max(n, 1)
+		c.checkpointFunc = checkpointFunc
+	}}
+}
+
+// WithResume starts iteration from a Checkpoint. The iterator input must implement io.Seeker.
+// If the input has a header, the header is read from the start of the input before seeking to the checkpoint.
+func WithResume(checkpoint Checkpoint) ReaderOption {
+	return option{reader: func(c *readerConfig) { c.checkpoint = &checkpoint }}
+}
+
+// WithCRLF terminates written records with \r\n rather than \n.
+func WithCRLF() WriterOption {
+	return option{writer: func(c *writerConfig) { c.useCRLF = true }}
+}
+
+// WithQuoteAll quotes every written field, rather than only the fields which require quoting.
+func WithQuoteAll() WriterOption {
+	return option{writer: func(c *writerConfig) { c.quoteAll = true }}
+}
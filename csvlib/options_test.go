@@ -0,0 +1,146 @@
+package csvlib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIterator_DialectOptions(t *testing.T) {
+	tests := map[string]struct {
+		csvData string
+		opts    []ReaderOption
+		want    [][]string
+	}{
+		"semicolon-crlf": {
+			csvData: "first_name;last_name\r\nJohn;Doe\r\nJane;Doe\r\n",
+			opts:    []ReaderOption{WithDelimiter(';')},
+			want:    [][]string{{"John", "Doe"}, {"Jane", "Doe"}},
+		},
+		"tab": {
+			csvData: "first_name\tlast_name\nJohn\tDoe\n",
+			opts:    []ReaderOption{WithDelimiter('\t')},
+			want:    [][]string{{"John", "Doe"}},
+		},
+		"comment": {
+			csvData: "first_name,last_name\n# skipped\nJohn,Doe\n",
+			opts:    []ReaderOption{WithComment('#')},
+			want:    [][]string{{"John", "Doe"}},
+		},
+		"lazy-quotes-and-trim": {
+			csvData: "first_name,last_name\nJo\"hn,  Doe\n",
+			opts:    []ReaderOption{WithLazyQuotes(), WithTrimLeadingSpace()},
+			want:    [][]string{{`Jo"hn`, "Doe"}},
+		},
+		"variable-fields": {
+			csvData: "first_name,last_name\nJohn\n",
+			opts:    []ReaderOption{WithFieldsPerRecord(-1)},
+			want:    [][]string{{"John"}},
+		},
+	}
+
+	parseFunc := func(fields []string) ([]string, error) {
+		return fields, nil
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			iter, err := NewDefaultIterator(strings.NewReader(tt.csvData), true, parseFunc, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewDefaultIterator unexpected error %v", err)
+			}
+
+			var got [][]string
+			for rec, err := range iter {
+				if err != nil {
+					t.Fatalf("iterator error = %v", err)
+				}
+				got = append(got, rec.Data)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("iterator found diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIterator_InvalidDialect(t *testing.T) {
+	parseFunc := func(fields []string) ([]string, error) {
+		return fields, nil
+	}
+
+	for name, opts := range map[string][]ReaderOption{
+		"delimiter":         {WithDelimiter('"')},
+		"comment-delimiter": {WithComment(',')},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewDefaultIterator(strings.NewReader(""), true, parseFunc, opts...); err == nil {
+				t.Error("expected an error for an invalid dialect")
+			}
+		})
+	}
+}
+
+func TestWriter_DialectOptions(t *testing.T) {
+	tests := map[string]struct {
+		opts []WriterOption
+		want string
+	}{
+		"semicolon-crlf": {
+			opts: []WriterOption{WithDelimiter(';'), WithCRLF()},
+			want: "first_name;last_name\r\nJohn;\"Doe;Jr\"\r\n",
+		},
+		"quote-all": {
+			opts: []WriterOption{WithQuoteAll()},
+			want: "\"first_name\",\"last_name\"\n\"John\",\"Doe;Jr\"\n",
+		},
+		"quote-all-escapes": {
+			opts: []WriterOption{WithQuoteAll(), WithCRLF(), WithDelimiter('|')},
+			want: "\"first_name\"|\"last_name\"\r\n\"John\"|\"Doe;Jr\"\r\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output bytes.Buffer
+			w, err := NewWriter(&output, customRecordConvertFunc, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewWriter unexpected error %v", err)
+			}
+			if err := w.WriteHeader([]string{"first_name", "last_name"}); err != nil {
+				t.Fatalf("WriteHeader unexpected error %v", err)
+			}
+			if err := w.Write(CustomRecord{FirstName: "John", LastName: "Doe;Jr"}); err != nil {
+				t.Fatalf("Write unexpected error %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close unexpected error %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, output.String()); diff != "" {
+				t.Errorf("Writer did not write expected contents (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriter_QuoteAllEmbeddedQuotes(t *testing.T) {
+	var output bytes.Buffer
+	w, err := NewWriter(&output, customRecordConvertFunc, WithQuoteAll(), WithCRLF())
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+	if err := w.Write(CustomRecord{FirstName: `Jo"hn`, LastName: "Doe\nJr"}); err != nil {
+		t.Fatalf("Write unexpected error %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+
+	want := "\"Jo\"\"hn\",\"Doe\r\nJr\"\r\n"
+	if diff := cmp.Diff(want, output.String()); diff != "" {
+		t.Errorf("Writer did not write expected contents (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,138 @@
+package csvlib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"runtime"
+	"sync"
+)
+
+// parallelWindowFactor bounds the number of records in flight per worker, limiting memory used to restore record order
+// when a worker falls behind.
+const parallelWindowFactor = 4
+
+// sequencedRecord is a rawRecord tagged with its position in the input.
+type sequencedRecord struct {
+	seq int
+	raw rawRecord
+}
+
+// sequencedResult is a parsed Record[T] tagged with its position in the input.
+type sequencedResult[T any] struct {
+	seq    int
+	raw    rawRecord
+	record Record[T]
+	err    error
+}
+
+// NewParallelIterator returns an iterator which reads records on a single goroutine and parses them with
+// conversionFunc on a pool of worker goroutines. Records are yielded in their original input order with the same
+// IterationError and ParseError semantics, and ErrorPolicy handling, as NewDefaultIterator.
+//
+// workers sets the number of parsing goroutines. runtime.GOMAXPROCS(0) is used if workers <= 0.
+// conversionFunc must be safe for concurrent use.
+//
+// All goroutines have exited by the time the range loop over the iterator completes, including when the consumer
+// breaks out of the loop early.
+func NewParallelIterator[T any](input io.Reader,
+	hasHeader bool,
+	workers int,
+	conversionFunc ParseFunc[T],
+	opts ...ReaderOption) (iter.Seq2[Record[T], error], error) {
+
+	if conversionFunc == nil {
+		err := errors.New("NewParallelIterator: conversionFunc is required")
+		return nil, NewIterationError(0, err)
+	}
+
+	cfg, err := newReaderConfig(DefaultBufferSize, opts)
+	if err == nil {
+		err = cfg.validateInput(input)
+	}
+	if err != nil {
+		return nil, NewIterationError(0, fmt.Errorf("NewParallelIterator: %w", err))
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	return func(yield func(Record[T], error) bool) {
+		tracker := newRecordTracker(&cfg)
+		records := readRecords(input, hasHeader, tracker.headerFunc(nil), &cfg)
+		done := make(chan struct{})
+		// window limits how far the reader may run ahead of the oldest record not yet yielded
+		window := make(chan struct{}, workers*parallelWindowFactor)
+		jobs := make(chan sequencedRecord, workers)
+		results := make(chan sequencedResult[T], workers)
+
+		var wg sync.WaitGroup
+
+		// reader
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(jobs)
+			seq := 0
+			for raw := range records {
+				select {
+				case window <- struct{}{}:
+				case <-done:
+					return
+				}
+				select {
+				case jobs <- sequencedRecord{seq: seq, raw: raw}:
+				case <-done:
+					return
+				}
+				seq++
+			}
+		}()
+
+		// parsers
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for job := range jobs {
+					record, err := parseRecord(job.raw, conversionFunc)
+					select {
+					case results <- sequencedResult[T]{seq: job.seq, raw: job.raw, record: record, err: err}:
+					case <-done:
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+
+		// signal the goroutines to exit and wait until the reader and parsers have done so
+		defer func() {
+			close(done)
+			for range results {
+			}
+		}()
+
+		pending := make(map[int]sequencedResult[T])
+		next := 0
+		for result := range results {
+			pending[result.seq] = result
+			for {
+				ready, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				<-window
+				if !yieldTracked(yield, tracker, ready.raw, ready.record, ready.err) {
+					return
+				}
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,90 @@
+package csvlib
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sequenceCsv returns a CSV payload with a header and n records containing their record number.
+func sequenceCsv(t *testing.T, n int) string {
+	t.Helper()
+	var sb strings.Builder
+	sb.WriteString("id\n")
+	for i := range n {
+		fmt.Fprintf(&sb, "%d\n", i)
+	}
+	return sb.String()
+}
+
+// slowParseFunc parses an id with a variable delay so that workers complete out of order.
+// ids divisible by 10 return an error.
+func slowParseFunc(fields []string) (int, error) {
+	id, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, err
+	}
+	time.Sleep(time.Duration(id%3) * time.Millisecond)
+	if id%10 == 0 {
+		return id, errors.New("test case error")
+	}
+	return id, nil
+}
+
+func TestParallelIterator_Order(t *testing.T) {
+	const n = 200
+	iter, err := NewParallelIterator(strings.NewReader(sequenceCsv(t, n)), true, 8, slowParseFunc)
+	if err != nil {
+		t.Fatalf("NewParallelIterator unexpected error %v", err)
+	}
+
+	count := 0
+	for rec, err := range iter {
+		wantLine := count + 2
+		if rec.LineNumber != wantLine {
+			t.Fatalf("record %d: line number = %d, want %d", count, rec.LineNumber, wantLine)
+		}
+		if count%10 == 0 {
+			var pe *ParseError
+			if !errors.As(err, &pe) || pe.LineNumber() != wantLine {
+				t.Errorf("record %d: expected a ParseError for line %d, got %v", count, wantLine, err)
+			}
+		} else if err != nil || rec.Data != count {
+			t.Errorf("record %d: got %d, %v", count, rec.Data, err)
+		}
+		count++
+	}
+	if count != n {
+		t.Errorf("iterator yielded %d records, want %d", count, n)
+	}
+}
+
+func TestParallelIterator_Break(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	iter, err := NewParallelIterator(strings.NewReader(sequenceCsv(t, 1000)), true, 4, slowParseFunc)
+	if err != nil {
+		t.Fatalf("NewParallelIterator unexpected error %v", err)
+	}
+	count := 0
+	for range iter {
+		count++
+		if count == 5 {
+			break
+		}
+	}
+
+	// allow the goroutine closing the results channel to return
+	after := runtime.NumGoroutine()
+	for i := 0; i < 100 && after > before; i++ {
+		time.Sleep(time.Millisecond)
+		after = runtime.NumGoroutine()
+	}
+	if after > before {
+		t.Errorf("goroutines leaked: before = %d, after = %d", before, after)
+	}
+}
@@ -0,0 +1,245 @@
+package csvlib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrErrorBudgetExceeded is wrapped by the IterationError which stops iteration when an ErrorPolicy's error budget is
+// exceeded.
+var ErrErrorBudgetExceeded = errors.New("error budget exceeded")
+
+// ErrorPolicy determines how an iterator handles IterationErrors and ParseErrors.
+// The zero value yields every error and continues iteration.
+//
+// Errors which end iteration regardless of policy, such as header errors and context cancellation, are always yielded.
+type ErrorPolicy struct {
+	// failFast stops iteration after yielding the first error.
+	failFast bool
+	// skip prevents errors from being yielded.
+	skip bool
+	// maxErrors, if > 0, stops iteration once the number of errors reaches maxErrors.
+	maxErrors int
+	// maxErrorRatio, if > 0, stops iteration once errors / records exceeds maxErrorRatio.
+	maxErrorRatio float64
+	// minRecords is the number of records read before maxErrorRatio is enforced.
+	minRecords int
+}
+
+// YieldErrors returns the default ErrorPolicy, which yields every error and continues iteration.
+func YieldErrors() ErrorPolicy {
+	return ErrorPolicy{}
+}
+
+// FailFast returns an ErrorPolicy which yields the first error and stops iteration.
+func FailFast() ErrorPolicy {
+	return ErrorPolicy{failFast: true}
+}
+
+// SkipErrors returns an ErrorPolicy which skips records with errors and continues iteration.
+func SkipErrors() ErrorPolicy {
+	return ErrorPolicy{skip: true}
+}
+
+// MaxErrors returns an ErrorPolicy which skips records with errors until n errors have occurred.
+// The nth error stops iteration with an IterationError wrapping ErrErrorBudgetExceeded and the nth error.
+// MaxErrors is equivalent to FailFast if n < 1.
+func MaxErrors(n int) ErrorPolicy {
+	return ErrorPolicy{skip: true, maxErrors: max(n, 1)}
+}
+
+// MaxErrorRatio returns an ErrorPolicy which skips records with errors until the ratio of errors to records read
+// exceeds ratio. The ratio is checked as each error occurs once minRecords records have been read, so that a single
+// early error does not stop iteration. Exceeding the ratio stops iteration with an IterationError wrapping
+// ErrErrorBudgetExceeded and the last error.
+func MaxErrorRatio(ratio float64, minRecords int) ErrorPolicy {
+	return ErrorPolicy{skip: true, maxErrorRatio: ratio, minRecords: minRecords}
+}
+
+// WithErrorPolicy sets the ErrorPolicy used by the iterator.
+func WithErrorPolicy(policy ErrorPolicy) ReaderOption {
+	return option{reader: func(c *readerConfig) { c.errorPolicy = policy }}
+}
+
+// WithRejects writes records with errors to rejects.
+// Rejected records are written regardless of the ErrorPolicy. If the input has a header, a reject header is written
+// before the first rejected record. See NewRejectWriter.
+// The iterator does not flush or close rejects.
+func WithRejects(rejects *Writer[Reject]) ReaderOption {
+	return option{reader: func(c *readerConfig) { c.rejects = rejects }}
+}
+
+// RejectHeader contains the leading columns of a reject record.
+// The raw fields of the rejected record follow these columns.
+var RejectHeader = []string{"line_number", "error"}
+
+// Reject is a record which could not be read or parsed.
+type Reject struct {
+	LineNumber int
+	// Fields contains the raw record fields, if available.
+	Fields []string
+	Err    error
+}
+
+// rejectToCsv converts a Reject to a csv record.
+func rejectToCsv(reject Reject) ([]string, error) {
+	csvFields := make([]string, 0, len(RejectHeader)+len(reject.Fields))
+	csvFields = append(csvFields, strconv.Itoa(reject.LineNumber), reject.Err.Error())
+	return append(csvFields, reject.Fields...), nil
+}
+
+// NewRejectWriter creates a new Writer for Reject records, for use with WithRejects.
+// Each reject is written as its line number, error message and raw record fields.
+func NewRejectWriter(output io.Writer, opts ...WriterOption) (Writer[Reject], error) {
+	return NewWriter(output, rejectToCsv, opts...)
+}
+
+// trackAction specifies how an iterator handles a tracked record.
+type trackAction int
+
+const (
+	// actionYield yields the record and continues iteration.
+	actionYield trackAction = iota
+	// actionSkip skips the record and continues iteration.
+	actionSkip
+	// actionStop yields the record's error and stops iteration.
+	actionStop
+)
+
+// recordTracker applies an ErrorPolicy and emits checkpoints for the records read by an iterator.
+type recordTracker struct {
+	policy  ErrorPolicy
+	rejects *Writer[Reject]
+	// checkpointEvery is the number of records between calls to checkpointFunc.
+	checkpointEvery int
+	checkpointFunc  func(Checkpoint)
+	// uncheckpointed is the number of records since the last checkpoint.
+	uncheckpointed int
+	// header is the input header, written to rejects prior to the first reject.
+	header            []string
+	wroteRejectHeader bool
+	records           int
+	errors            int
+}
+
+// newRecordTracker returns a recordTracker for a single iteration using the settings configured in cfg.
+func newRecordTracker(cfg *readerConfig) *recordTracker {
+	return &recordTracker{
+		policy:          cfg.errorPolicy,
+		rejects:         cfg.rejects,
+		checkpointEvery: cfg.checkpointEvery,
+		checkpointFunc:  cfg.checkpointFunc,
+	}
+}
+
+// headerFunc returns a header function which records the header for rejects before calling next, if not nil.
+func (t *recordTracker) headerFunc(next func([]string) error) func([]string) error {
+	return func(header []string) error {
+		t.header = header
+		if next != nil {
+			return next(header)
+		}
+		return nil
+	}
+}
+
+// track records the outcome of reading and parsing raw, returning the error to yield and the action to take.
+func (t *recordTracker) track(raw rawRecord, err error) (error, trackAction) {
+	if raw.terminal {
+		return err, actionStop
+	}
+
+	t.records++
+	if err == nil {
+		return nil, actionYield
+	}
+
+	t.errors++
+	if rejectErr := t.reject(raw, err); rejectErr != nil {
+		ie := NewIterationError(0, rejectErr)
+		ie.setPosition(raw)
+		return ie, actionStop
+	}
+
+	switch {
+	case t.policy.failFast:
+		return err, actionStop
+	case t.policy.maxErrors > 0 && t.errors >= t.policy.maxErrors:
+		return t.budgetError(raw, err), actionStop
+	case t.ratioExceeded():
+		return t.budgetError(raw, err), actionStop
+	case t.policy.skip:
+		return nil, actionSkip
+	}
+	return err, actionYield
+}
+
+// ratioExceeded reports whether the ratio of errors to records exceeds the policy's maximum error ratio.
+func (t *recordTracker) ratioExceeded() bool {
+	if t.policy.maxErrorRatio <= 0 || t.records < t.policy.minRecords {
+		return false
+	}
+	return float64(t.errors)/float64(t.records) > t.policy.maxErrorRatio
+}
+
+// budgetError returns the IterationError which stops iteration when the error budget is exceeded.
+func (t *recordTracker) budgetError(raw rawRecord, lastErr error) error {
+	err := fmt.Errorf("%w: %d errors in %d records, last error: %w", ErrErrorBudgetExceeded, t.errors, t.records, lastErr)
+	ie := NewIterationError(0, err)
+	ie.setPosition(raw)
+	return ie
+}
+
+// reject writes a rejected record to the rejects Writer, if configured.
+func (t *recordTracker) reject(raw rawRecord, err error) error {
+	if t.rejects == nil {
+		return nil
+	}
+	if !t.wroteRejectHeader && t.header != nil {
+		if headerErr := t.rejects.WriteHeader(append(append([]string(nil), RejectHeader...), t.header...)); headerErr != nil {
+			return fmt.Errorf("error writing reject header %w", headerErr)
+		}
+	}
+	t.wroteRejectHeader = true
+
+	if writeErr := t.rejects.Write(Reject{LineNumber: raw.lineNumber, Fields: raw.fields, Err: err}); writeErr != nil {
+		return fmt.Errorf("error writing reject %w", writeErr)
+	}
+	return nil
+}
+
+// checkpoint calls the checkpointFunc with raw's next Checkpoint once checkpointEvery records have been processed.
+func (t *recordTracker) checkpoint(raw rawRecord) {
+	if t.checkpointFunc == nil {
+		return
+	}
+	t.uncheckpointed++
+	if t.uncheckpointed >= t.checkpointEvery {
+		t.uncheckpointed = 0
+		t.checkpointFunc(raw.next)
+	}
+}
+
+// yieldTracked applies the recordTracker to a parsed record and yields it, if applicable.
+// yieldTracked returns false if iteration should stop.
+func yieldTracked[T any](yield func(Record[T], error) bool,
+	tracker *recordTracker,
+	raw rawRecord,
+	record Record[T],
+	err error) bool {
+
+	err, action := tracker.track(raw, err)
+	switch action {
+	case actionStop:
+		yield(Record[T]{LineNumber: raw.lineNumber, Offset: raw.offset, Raw: raw.raw, Source: raw.source}, err)
+		return false
+	case actionYield:
+		if !yield(record, err) {
+			return false
+		}
+	}
+	tracker.checkpoint(raw)
+	return true
+}
@@ -0,0 +1,103 @@
+package csvlib
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// policyCsv contains records for slowParseFunc with errors in lines 4 and 6.
+const policyCsv = "id\n1\n2\nx\n3\n0\n4\n"
+
+// policyResult summarizes the records and errors yielded by an iterator.
+type policyResult struct {
+	Ids    []int
+	Lines  []int
+	Budget bool
+}
+
+func TestErrorPolicy(t *testing.T) {
+	tests := map[string]struct {
+		policy ErrorPolicy
+		want   policyResult
+	}{
+		"yield-errors": {
+			policy: YieldErrors(),
+			want:   policyResult{Ids: []int{1, 2, 3, 4}, Lines: []int{4, 6}},
+		},
+		"fail-fast": {
+			policy: FailFast(),
+			want:   policyResult{Ids: []int{1, 2}, Lines: []int{4}},
+		},
+		"skip-errors": {
+			policy: SkipErrors(),
+			want:   policyResult{Ids: []int{1, 2, 3, 4}},
+		},
+		"max-errors": {
+			policy: MaxErrors(2),
+			want:   policyResult{Ids: []int{1, 2, 3}, Lines: []int{6}, Budget: true},
+		},
+		"max-error-ratio-within-budget": {
+			policy: MaxErrorRatio(0.5, 1),
+			want:   policyResult{Ids: []int{1, 2, 3, 4}},
+		},
+		"max-error-ratio-exceeded": {
+			policy: MaxErrorRatio(0.2, 4),
+			want:   policyResult{Ids: []int{1, 2, 3}, Lines: []int{6}, Budget: true},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			iter, err := NewDefaultIterator(strings.NewReader(policyCsv), true, slowParseFunc, WithErrorPolicy(tt.policy))
+			if err != nil {
+				t.Fatalf("NewDefaultIterator unexpected error %v", err)
+			}
+
+			var got policyResult
+			for rec, err := range iter {
+				if err != nil {
+					got.Lines = append(got.Lines, rec.LineNumber)
+					got.Budget = got.Budget || errors.Is(err, ErrErrorBudgetExceeded)
+					continue
+				}
+				got.Ids = append(got.Ids, rec.Data)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("iterator found diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestErrorPolicy_Rejects(t *testing.T) {
+	var output bytes.Buffer
+	rejects, err := NewRejectWriter(&output)
+	if err != nil {
+		t.Fatalf("NewRejectWriter unexpected error %v", err)
+	}
+
+	iter, err := NewDefaultIterator(strings.NewReader(policyCsv), true, slowParseFunc,
+		WithErrorPolicy(SkipErrors()), WithRejects(&rejects))
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	for _, err := range iter {
+		if err != nil {
+			t.Errorf("iterator error = %v", err)
+		}
+	}
+	if err := rejects.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+
+	want := "line_number,error,id\n" +
+		`4,"ParseError error in line 4 strconv.Atoi: parsing ""x"": invalid syntax",x` + "\n" +
+		"6,ParseError error in line 6 test case error,0\n"
+	if diff := cmp.Diff(want, output.String()); diff != "" {
+		t.Errorf("rejects found diff (-want +got):\n%s", diff)
+	}
+}
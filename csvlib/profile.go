@@ -0,0 +1,499 @@
+package csvlib
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultTopK is the default number of most frequent values reported for each column.
+	DefaultTopK = 10
+	// DefaultPercentileSampleSize is the default number of values sampled for percentile estimates.
+	DefaultPercentileSampleSize = 10000
+	// minTopValueCounters is the minimum number of counters used to find each column's top values.
+	minTopValueCounters = 1000
+)
+
+// DefaultPercentiles are the percentiles reported for numeric columns by default.
+var DefaultPercentiles = []float64{0.25, 0.5, 0.75, 0.9, 0.99}
+
+// ProfileSpec configures ProfileFile.
+type ProfileSpec struct {
+	// TopK is the number of most frequent values reported for each column. DefaultTopK is used if TopK is 0, and top
+	// values are not reported if TopK < 0.
+	TopK int
+	// Percentiles lists the percentiles, between 0 and 1, reported for numeric columns. DefaultPercentiles is used if
+	// Percentiles is nil.
+	Percentiles []float64
+	// SampleSize is the number of values sampled from each numeric column to estimate percentiles.
+	// DefaultPercentileSampleSize is used if SampleSize <= 0.
+	SampleSize int
+}
+
+// ValueCount is a column value and the number of times it occurs.
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// PercentileValue is the estimated value of a percentile.
+type PercentileValue struct {
+	Percentile float64 `json:"percentile"`
+	Value      float64 `json:"value"`
+}
+
+// ColumnProfile contains the statistics for a column.
+//
+// DistinctEstimate is a HyperLogLog estimate, typically within 2% of the number of distinct values. TopValues are
+// exact for columns with up to 1000, or ten times TopK, distinct values. Otherwise they approximate the most frequent
+// values, with counts which may be overestimated. Percentiles are estimated from a uniform sample of the column's values.
+type ColumnProfile struct {
+	Name string `json:"name"`
+	// Count is the number of non-empty values.
+	Count int `json:"count"`
+	// Nulls is the number of empty or missing values.
+	Nulls            int    `json:"nulls"`
+	DistinctEstimate uint64 `json:"distinct_estimate"`
+	// MaxLength is the maximum value length in characters.
+	MaxLength int `json:"max_length"`
+	// Numeric is true if every non-empty value is a number.
+	Numeric bool `json:"numeric"`
+	// Min and Max are the least and greatest values, compared as numbers for Numeric columns.
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+	// Mean and Percentiles are reported for Numeric columns.
+	Mean        *float64          `json:"mean,omitempty"`
+	Percentiles []PercentileValue `json:"percentiles,omitempty"`
+	TopValues   []ValueCount      `json:"top_values,omitempty"`
+}
+
+// Profile contains the statistics for each column of a CSV file.
+type Profile struct {
+	// Records is the number of records profiled.
+	Records int `json:"records"`
+	// InvalidRecords is the number of malformed records, which are not profiled.
+	InvalidRecords int             `json:"invalid_records"`
+	Columns        []ColumnProfile `json:"columns"`
+}
+
+// ProfileFile streams the records following the header in input and returns the statistics for each column.
+// Malformed records are counted and not profiled.
+func ProfileFile(input io.Reader, spec ProfileSpec, opts ...ReaderOption) (*Profile, error) {
+	if spec.TopK == 0 {
+		spec.TopK = DefaultTopK
+	}
+	if spec.Percentiles == nil {
+		spec.Percentiles = DefaultPercentiles
+	}
+	for _, p := range spec.Percentiles {
+		if p < 0 || p > 1 || math.IsNaN(p) {
+			return nil, fmt.Errorf("ProfileFile: invalid percentile %v", p)
+		}
+	}
+	if spec.SampleSize <= 0 {
+		spec.SampleSize = DefaultPercentileSampleSize
+	}
+
+	var profilers []*columnProfiler
+	headerFunc := func(fields []string) error {
+		profilers = make([]*columnProfiler, len(fields))
+		for i, name := range fields {
+			profilers[i] = newColumnProfiler(name, spec, uint64(i))
+		}
+		return nil
+	}
+	parseFunc := func(fields []string) ([]string, error) {
+		return fields, nil
+	}
+
+	records, err := iterator(input, true, DefaultBufferSize, headerFunc, parseFunc, opts)
+	if err != nil {
+		return nil, fmt.Errorf("ProfileFile: %w", err)
+	}
+
+	profile := &Profile{}
+	for rec, err := range records {
+		if err != nil {
+			if isRecordError(err) {
+				profile.InvalidRecords++
+				continue
+			}
+			return nil, fmt.Errorf("ProfileFile: %w", err)
+		}
+		profile.Records++
+		for i, p := range profilers {
+			var value string
+			if i < len(rec.Data) {
+				value = rec.Data[i]
+			}
+			p.observe(value)
+		}
+	}
+	if profilers == nil {
+		return nil, errors.New("ProfileFile: input does not have a header")
+	}
+
+	profile.Columns = make([]ColumnProfile, len(profilers))
+	for i, p := range profilers {
+		profile.Columns[i] = p.result()
+	}
+	return profile, nil
+}
+
+// WriteJSON writes the profile to output as indented JSON.
+func (p *Profile) WriteJSON(output io.Writer) error {
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(p); err != nil {
+		return fmt.Errorf("Profile.WriteJSON: %w", err)
+	}
+	return nil
+}
+
+// ReportHeader returns the header of the profile's CSV report, which includes a column for each reported percentile.
+func (p *Profile) ReportHeader() []string {
+	header := []string{"column", "count", "nulls", "distinct_estimate", "max_length", "numeric", "min", "max", "mean"}
+	for _, pv := range p.percentiles() {
+		header = append(header, "p"+strconv.FormatFloat(pv*100, 'f', -1, 64))
+	}
+	return append(header, "top_values")
+}
+
+// percentiles returns the percentiles reported for numeric columns.
+func (p *Profile) percentiles() []float64 {
+	for _, c := range p.Columns {
+		if c.Numeric && c.Percentiles != nil {
+			percentiles := make([]float64, len(c.Percentiles))
+			for i, pv := range c.Percentiles {
+				percentiles[i] = pv.Percentile
+			}
+			return percentiles
+		}
+	}
+	return nil
+}
+
+// WriteReport writes the profile to output as a CSV report with ReportHeader and a row for each column.
+// Top values are written in a single field as quoted values followed by their counts, e.g. "a" (2), "b" (1).
+func (p *Profile) WriteReport(output io.Writer, opts ...WriterOption) error {
+	percentiles := p.percentiles()
+	convertFunc := func(c ColumnProfile) ([]string, error) {
+		formatFloat := func(f float64) string {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		row := []string{
+			c.Name,
+			strconv.Itoa(c.Count),
+			strconv.Itoa(c.Nulls),
+			strconv.FormatUint(c.DistinctEstimate, 10),
+			strconv.Itoa(c.MaxLength),
+			strconv.FormatBool(c.Numeric),
+			c.Min,
+			c.Max,
+			"",
+		}
+		if c.Mean != nil {
+			row[len(row)-1] = formatFloat(*c.Mean)
+		}
+		for i := range percentiles {
+			var value string
+			if i < len(c.Percentiles) {
+				value = formatFloat(c.Percentiles[i].Value)
+			}
+			row = append(row, value)
+		}
+		topValues := make([]string, len(c.TopValues))
+		for i, vc := range c.TopValues {
+			topValues[i] = fmt.Sprintf("%q (%d)", vc.Value, vc.Count)
+		}
+		return append(row, strings.Join(topValues, ", ")), nil
+	}
+
+	writer, err := NewWriter(output, convertFunc, opts...)
+	if err != nil {
+		return fmt.Errorf("Profile.WriteReport: %w", err)
+	}
+	if err := writer.WriteHeader(p.ReportHeader()); err != nil {
+		return fmt.Errorf("Profile.WriteReport: %w", err)
+	}
+	for _, c := range p.Columns {
+		if err := writer.Write(c); err != nil {
+			return fmt.Errorf("Profile.WriteReport: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("Profile.WriteReport: %w", err)
+	}
+	return nil
+}
+
+// columnProfiler accumulates the statistics for a single column.
+type columnProfiler struct {
+	spec      ProfileSpec
+	name      string
+	count     int
+	nulls     int
+	maxLength int
+	distinct  *hyperLogLog
+	top       *spaceSaving
+	// minText and maxText are the least and greatest values compared as strings.
+	minText string
+	maxText string
+
+	// numeric statistics are tracked until a value is not a number
+	numeric  bool
+	min, max float64
+	// minValue and maxValue are the values of min and max as written in the input
+	minValue string
+	maxValue string
+	mean     float64
+	sample   []float64
+	random   *rand.Rand
+}
+
+// newColumnProfiler returns a columnProfiler. seed makes each column's percentile sample deterministic.
+func newColumnProfiler(name string, spec ProfileSpec, seed uint64) *columnProfiler {
+	p := &columnProfiler{
+		spec:     spec,
+		name:     name,
+		distinct: newHyperLogLog(),
+		numeric:  true,
+		random:   rand.New(rand.NewPCG(seed, 0x9e3779b97f4a7c15)),
+	}
+	if spec.TopK > 0 {
+		p.top = newSpaceSaving(max(spec.TopK*10, minTopValueCounters))
+	}
+	return p
+}
+
+// observe updates the column statistics with a value.
+func (p *columnProfiler) observe(value string) {
+	if value == "" {
+		p.nulls++
+		return
+	}
+	p.count++
+	p.maxLength = max(p.maxLength, utf8.RuneCountInString(value))
+	p.distinct.add(value)
+	if p.top != nil {
+		p.top.add(value)
+	}
+	if p.count == 1 || value < p.minText {
+		p.minText = value
+	}
+	if p.count == 1 || value > p.maxText {
+		p.maxText = value
+	}
+
+	if !p.numeric {
+		return
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		p.numeric, p.sample = false, nil
+		return
+	}
+	if p.count == 1 || number < p.min {
+		p.min, p.minValue = number, value
+	}
+	if p.count == 1 || number > p.max {
+		p.max, p.maxValue = number, value
+	}
+	p.mean += (number - p.mean) / float64(p.count)
+
+	// reservoir sampling retains a uniform sample of the values
+	if len(p.sample) < p.spec.SampleSize {
+		p.sample = append(p.sample, number)
+	} else if i := p.random.IntN(p.count); i < p.spec.SampleSize {
+		p.sample[i] = number
+	}
+}
+
+// result returns the ColumnProfile for the observed values.
+func (p *columnProfiler) result() ColumnProfile {
+	cp := ColumnProfile{
+		Name:             p.name,
+		Count:            p.count,
+		Nulls:            p.nulls,
+		DistinctEstimate: min(p.distinct.estimate(), uint64(p.count)),
+		MaxLength:        p.maxLength,
+		Numeric:          p.numeric && p.count > 0,
+		Min:              p.minText,
+		Max:              p.maxText,
+	}
+	if p.top != nil {
+		cp.TopValues = p.top.top(p.spec.TopK)
+	}
+	if !cp.Numeric {
+		return cp
+	}
+
+	cp.Min, cp.Max = p.minValue, p.maxValue
+	mean := p.mean
+	cp.Mean = &mean
+	slices.Sort(p.sample)
+	cp.Percentiles = make([]PercentileValue, len(p.spec.Percentiles))
+	for i, percentile := range p.spec.Percentiles {
+		cp.Percentiles[i] = PercentileValue{Percentile: percentile, Value: interpolate(p.sample, percentile)}
+	}
+	return cp
+}
+
+// interpolate returns the percentile of sorted values using linear interpolation between closest ranks.
+func interpolate(sorted []float64, percentile float64) float64 {
+	rank := percentile * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// hyperLogLogPrecision is the number of hash bits which select a hyperLogLog register.
+const hyperLogLogPrecision = 14
+
+// hyperLogLog estimates the number of distinct values added to it.
+type hyperLogLog struct {
+	registers []uint8
+}
+
+// newHyperLogLog returns an empty hyperLogLog.
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{registers: make([]uint8, 1<<hyperLogLogPrecision)}
+}
+
+// add adds a value to the hyperLogLog.
+func (h *hyperLogLog) add(value string) {
+	hash := fnv.New64a()
+	hash.Write([]byte(value))
+	x := mix64(hash.Sum64())
+
+	index := x >> (64 - hyperLogLogPrecision)
+	rank := uint8(bits.LeadingZeros64(x<<hyperLogLogPrecision|1<<(hyperLogLogPrecision-1)) + 1)
+	h.registers[index] = max(h.registers[index], rank)
+}
+
+// estimate returns the estimated number of distinct values, using linear counting for small cardinalities.
+func (h *hyperLogLog) estimate() uint64 {
+	m := float64(len(h.registers))
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
+
+// mix64 is the splitmix64 finalizer, which spreads FNV hash bits over the whole 64-bit value.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// spaceSaving tracks the most frequent values using the Space-Saving algorithm with a fixed number of counters.
+type spaceSaving struct {
+	capacity int
+	counters map[string]*valueCounter
+	// heap orders counters by count, least first
+	heap counterHeap
+}
+
+// valueCounter is a Space-Saving counter.
+type valueCounter struct {
+	value string
+	count int
+	index int
+}
+
+// newSpaceSaving returns a spaceSaving with capacity counters.
+func newSpaceSaving(capacity int) *spaceSaving {
+	return &spaceSaving{capacity: capacity, counters: make(map[string]*valueCounter, capacity)}
+}
+
+// add counts a value, replacing the least frequent counter if every counter is in use.
+func (s *spaceSaving) add(value string) {
+	if c, ok := s.counters[value]; ok {
+		c.count++
+		heap.Fix(&s.heap, c.index)
+		return
+	}
+	if len(s.counters) < s.capacity {
+		c := &valueCounter{value: value, count: 1}
+		s.counters[value] = c
+		heap.Push(&s.heap, c)
+		return
+	}
+
+	c := s.heap[0]
+	delete(s.counters, c.value)
+	c.value = value
+	c.count++
+	s.counters[value] = c
+	heap.Fix(&s.heap, 0)
+}
+
+// top returns the k most frequent values, most frequent first, with ties ordered by value.
+func (s *spaceSaving) top(k int) []ValueCount {
+	counts := make([]ValueCount, 0, len(s.counters))
+	for _, c := range s.counters {
+		counts = append(counts, ValueCount{Value: c.value, Count: c.count})
+	}
+	slices.SortFunc(counts, func(a, b ValueCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+	return counts[:min(k, len(counts))]
+}
+
+// counterHeap is a heap.Interface of valueCounters ordered by count.
+type counterHeap []*valueCounter
+
+func (h counterHeap) Len() int {
+	return len(h)
+}
+
+func (h counterHeap) Less(i, j int) bool {
+	return h[i].count < h[j].count
+}
+
+func (h counterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *counterHeap) Push(x any) {
+	c := x.(*valueCounter)
+	c.index = len(*h)
+	*h = append(*h, c)
+}
+
+func (h *counterHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
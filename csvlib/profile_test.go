@@ -0,0 +1,161 @@
+package csvlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func TestProfileFile(t *testing.T) {
+	csvData := "id,name,score,note\n" +
+		"1,ann,10,\n" +
+		"2,bob,20.5,x\n" +
+		"3,ann,,\n" +
+		"4,Zoë,-3,inf\n" +
+		"5\n" +
+		"6,ann,40\n"
+
+	profile, err := ProfileFile(strings.NewReader(csvData), ProfileSpec{TopK: 2, Percentiles: []float64{0, 0.5, 1}},
+		WithFieldsPerRecord(-1))
+	if err != nil {
+		t.Fatalf("ProfileFile unexpected error %v", err)
+	}
+
+	want := &Profile{
+		Records: 6,
+		Columns: []ColumnProfile{
+			{
+				Name: "id", Count: 6, DistinctEstimate: 6, MaxLength: 1, Numeric: true, Min: "1", Max: "6",
+				Mean: float64Ptr(3.5),
+				Percentiles: []PercentileValue{
+					{Percentile: 0, Value: 1}, {Percentile: 0.5, Value: 3.5}, {Percentile: 1, Value: 6},
+				},
+				TopValues: []ValueCount{{Value: "1", Count: 1}, {Value: "2", Count: 1}},
+			},
+			{
+				Name: "name", Count: 5, Nulls: 1, DistinctEstimate: 3, MaxLength: 3, Min: "Zoë", Max: "bob",
+				TopValues: []ValueCount{{Value: "ann", Count: 3}, {Value: "Zoë", Count: 1}},
+			},
+			{
+				Name: "score", Count: 4, Nulls: 2, DistinctEstimate: 4, MaxLength: 4, Numeric: true, Min: "-3",
+				Max: "40", Mean: float64Ptr(16.875),
+				Percentiles: []PercentileValue{
+					{Percentile: 0, Value: -3}, {Percentile: 0.5, Value: 15.25}, {Percentile: 1, Value: 40},
+				},
+				TopValues: []ValueCount{{Value: "-3", Count: 1}, {Value: "10", Count: 1}},
+			},
+			{
+				Name: "note", Count: 2, Nulls: 4, DistinctEstimate: 2, MaxLength: 3, Min: "inf", Max: "x",
+				TopValues: []ValueCount{{Value: "inf", Count: 1}, {Value: "x", Count: 1}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, profile); diff != "" {
+		t.Errorf("ProfileFile found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestProfileFile_Estimates(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("id,group\n")
+	const records = 50000
+	for i := range records {
+		fmt.Fprintf(&input, "%d,g%d\n", i, i%100)
+	}
+
+	profile, err := ProfileFile(strings.NewReader(input.String()), ProfileSpec{TopK: -1})
+	if err != nil {
+		t.Fatalf("ProfileFile unexpected error %v", err)
+	}
+
+	id, group := profile.Columns[0], profile.Columns[1]
+	if errorRate := math.Abs(float64(id.DistinctEstimate)-records) / records; errorRate > 0.03 {
+		t.Errorf("id distinct estimate = %d, want within 3%% of %d", id.DistinctEstimate, records)
+	}
+	if group.DistinctEstimate < 98 || group.DistinctEstimate > 102 {
+		t.Errorf("group distinct estimate = %d, want 100 +/- 2", group.DistinctEstimate)
+	}
+	median := id.Percentiles[1].Value
+	if math.Abs(median-records/2) > records*0.03 {
+		t.Errorf("id median = %v, want within 3%% of %d", median, records/2)
+	}
+	if id.TopValues != nil {
+		t.Errorf("id top values = %v, want nil", id.TopValues)
+	}
+}
+
+func TestProfileFile_TopValues(t *testing.T) {
+	// frequent values are found among many infrequent values
+	var input strings.Builder
+	input.WriteString("value\n")
+	for i := range 50000 {
+		fmt.Fprintf(&input, "v%d\n", i)
+		if i%10 == 0 {
+			input.WriteString("common\n")
+		}
+		if i%20 == 0 {
+			input.WriteString("frequent\n")
+		}
+	}
+
+	profile, err := ProfileFile(strings.NewReader(input.String()), ProfileSpec{TopK: 2})
+	if err != nil {
+		t.Fatalf("ProfileFile unexpected error %v", err)
+	}
+	top := profile.Columns[0].TopValues
+	if len(top) != 2 || top[0].Value != "common" || top[1].Value != "frequent" || top[0].Count < 5000 {
+		t.Errorf("top values = %v, want common and frequent", top)
+	}
+}
+
+func TestProfile_Output(t *testing.T) {
+	csvData := "name,score\nann,1\nbob,2\nann,\n"
+	profile, err := ProfileFile(strings.NewReader(csvData), ProfileSpec{Percentiles: []float64{0.5}})
+	if err != nil {
+		t.Fatalf("ProfileFile unexpected error %v", err)
+	}
+
+	var report bytes.Buffer
+	if err := profile.WriteReport(&report); err != nil {
+		t.Fatalf("WriteReport unexpected error %v", err)
+	}
+	want := "column,count,nulls,distinct_estimate,max_length,numeric,min,max,mean,p50,top_values\n" +
+		"name,3,0,2,3,false,ann,bob,,,\"\"\"ann\"\" (2), \"\"bob\"\" (1)\"\n" +
+		"score,2,1,2,1,true,1,2,1.5,1.5,\"\"\"1\"\" (1), \"\"2\"\" (1)\"\n"
+	if diff := cmp.Diff(want, report.String()); diff != "" {
+		t.Errorf("WriteReport found diff (-want +got):\n%s", diff)
+	}
+
+	var output bytes.Buffer
+	if err := profile.WriteJSON(&output); err != nil {
+		t.Fatalf("WriteJSON unexpected error %v", err)
+	}
+	var decoded Profile
+	if err := json.Unmarshal(output.Bytes(), &decoded); err != nil {
+		t.Fatalf("json.Unmarshal unexpected error %v", err)
+	}
+	if diff := cmp.Diff(profile, &decoded); diff != "" {
+		t.Errorf("WriteJSON round trip found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestProfileFile_Errors(t *testing.T) {
+	if _, err := ProfileFile(strings.NewReader(""), ProfileSpec{}); err == nil {
+		t.Error("ProfileFile expected error for input without a header")
+	}
+	if _, err := ProfileFile(strings.NewReader("a\n1\n"), ProfileSpec{Percentiles: []float64{2}}); err == nil {
+		t.Error("ProfileFile expected error for invalid percentile")
+	}
+	if _, err := ProfileFile(failingReader{data: strings.NewReader("a\n1\n")}, ProfileSpec{}); err == nil {
+		t.Error("ProfileFile expected error for read error")
+	}
+}
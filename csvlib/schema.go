@@ -0,0 +1,345 @@
+package csvlib
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ColumnType is the data type of a schema Column.
+type ColumnType int
+
+const (
+	// StringType accepts any value.
+	StringType ColumnType = iota
+	// IntType accepts base 10 integers.
+	IntType
+	// FloatType accepts floating point numbers.
+	FloatType
+	// BoolType accepts values parsed by strconv.ParseBool.
+	BoolType
+	// TimeType accepts times formatted with the Column's Layout.
+	TimeType
+)
+
+// String returns the ColumnType name.
+func (ct ColumnType) String() string {
+	switch ct {
+	case StringType:
+		return "string"
+	case IntType:
+		return "int"
+	case FloatType:
+		return "float"
+	case BoolType:
+		return "bool"
+	case TimeType:
+		return "time"
+	}
+	return fmt.Sprintf("ColumnType(%d)", int(ct))
+}
+
+// Column declares the validation rules for a CSV column.
+type Column struct {
+	// Name is the header column name. Columns are matched by position if the input does not have a header.
+	Name string
+	Type ColumnType
+	// Layout is the time layout for TimeType columns. time.RFC3339 is used if Layout is empty.
+	Layout string
+	// Required columns must be present in the header.
+	Required bool
+	// Nullable columns may contain empty values. Rules other than Required are not applied to empty values.
+	Nullable bool
+	// Pattern, if not nil, must match the value.
+	Pattern *regexp.Regexp
+	// Enum, if not empty, lists the allowed values.
+	Enum []string
+	// Min and Max, if not nil, bound IntType and FloatType values, and the length of StringType values.
+	Min *float64
+	Max *float64
+	// Unique values may not repeat within the input.
+	Unique bool
+}
+
+// Bound returns a pointer to v for use as a Column Min or Max.
+func Bound(v float64) *float64 {
+	return &v
+}
+
+// Schema declares the columns of a CSV file and their validation rules.
+type Schema struct {
+	Columns []Column
+}
+
+// Violation describes a value which does not satisfy a validation rule.
+type Violation struct {
+	Column string
+	Value  string
+	Reason string
+}
+
+// String returns the Violation's column and reason.
+func (v Violation) String() string {
+	return fmt.Sprintf("column %q: %s", v.Column, v.Reason)
+}
+
+// ValidationError is returned when a record does not satisfy its validation rules.
+// Violations lists every failing column in the record.
+type ValidationError struct {
+	*baseError
+	Violations []Violation
+}
+
+// Error returns context specific ValidationError information, including each Violation.
+func (ve *ValidationError) Error() string {
+	messages := make([]string, len(ve.Violations))
+	for i, v := range ve.Violations {
+		messages[i] = v.String()
+	}
+	return ve.format("ValidationError") + " " + strings.Join(messages, "; ")
+}
+
+// NewValidationError returns a ValidationError with the specified context information.
+func NewValidationError(lineNumber int, violations []Violation) *ValidationError {
+	return &ValidationError{baseError: &baseError{lineNumber: lineNumber}, Violations: violations}
+}
+
+// WithSchema validates each record against schema before it is parsed.
+// Records with violations are returned as a ValidationError rather than being passed to the ParseFunc. If the input
+// has a header, a missing Required column is returned as an IterationError wrapping ErrMissingColumn.
+func WithSchema(schema *Schema) ReaderOption {
+	return option{reader: func(c *readerConfig) { c.schema = schema }}
+}
+
+// validate returns an error if the schema's columns are not well-formed.
+func (s *Schema) validate() error {
+	seen := make(map[string]bool, len(s.Columns))
+	for _, c := range s.Columns {
+		if c.Name == "" {
+			return errors.New("schema column name is required")
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("schema has duplicate column %q", c.Name)
+		}
+		seen[c.Name] = true
+		if c.Type < StringType || c.Type > TimeType {
+			return fmt.Errorf("schema column %q has invalid type %s", c.Name, c.Type)
+		}
+	}
+	return nil
+}
+
+// boundColumn is a schema Column bound to its field position for a single iteration.
+type boundColumn struct {
+	Column
+	index  int
+	enum   map[string]bool
+	unique map[string]bool
+}
+
+// schemaValidator validates records against a Schema bound to an input header.
+type schemaValidator struct {
+	columns []boundColumn
+}
+
+// bind returns a schemaValidator for the input header. Columns are bound by position if header is nil.
+func (s *Schema) bind(header []string) (*schemaValidator, error) {
+	var h *Header
+	if header != nil {
+		h = NewHeader(header)
+		var required []string
+		for _, c := range s.Columns {
+			if c.Required {
+				required = append(required, c.Name)
+			}
+		}
+		if err := h.Require(required...); err != nil {
+			return nil, err
+		}
+	}
+
+	v := &schemaValidator{columns: make([]boundColumn, 0, len(s.Columns))}
+	for i, c := range s.Columns {
+		bc := boundColumn{Column: c, index: i}
+		if h != nil {
+			index, ok := h.Index(c.Name)
+			if !ok {
+				continue
+			}
+			bc.index = index
+		}
+		if bc.Layout == "" {
+			bc.Layout = time.RFC3339
+		}
+		if len(c.Enum) > 0 {
+			bc.enum = make(map[string]bool, len(c.Enum))
+			for _, e := range c.Enum {
+				bc.enum[e] = true
+			}
+		}
+		if c.Unique {
+			bc.unique = make(map[string]bool)
+		}
+		v.columns = append(v.columns, bc)
+	}
+	return v, nil
+}
+
+// validate returns the Violations for a record's fields.
+func (v *schemaValidator) validate(fields []string) []Violation {
+	var violations []Violation
+	for i := range v.columns {
+		c := &v.columns[i]
+		if c.index >= len(fields) {
+			if c.Required {
+				violations = append(violations, Violation{Column: c.Name, Reason: "value is missing"})
+			}
+			continue
+		}
+		if reason := c.check(fields[c.index]); reason != "" {
+			violations = append(violations, Violation{Column: c.Name, Value: fields[c.index], Reason: reason})
+		}
+	}
+	return violations
+}
+
+// check returns the reason value violates the column's rules, or "" if the value is valid.
+func (c *boundColumn) check(value string) string {
+	if value == "" {
+		if c.Nullable {
+			return ""
+		}
+		return "value is required"
+	}
+
+	var number float64
+	var integer int64
+	var err error
+	switch c.Type {
+	case IntType:
+		integer, err = strconv.ParseInt(value, 10, 64)
+		number = float64(integer)
+	case FloatType:
+		number, err = strconv.ParseFloat(value, 64)
+	case BoolType:
+		_, err = strconv.ParseBool(value)
+	case TimeType:
+		_, err = time.Parse(c.Layout, value)
+	case StringType:
+		number = float64(utf8.RuneCountInString(value))
+	}
+	if err != nil {
+		return fmt.Sprintf("value %q is not a valid %s", value, c.Type)
+	}
+
+	if c.Pattern != nil && !c.Pattern.MatchString(value) {
+		return fmt.Sprintf("value %q does not match pattern %q", value, c.Pattern)
+	}
+	if c.enum != nil && !c.enum[value] {
+		return fmt.Sprintf("value %q is not one of %q", value, c.Enum)
+	}
+	if c.Type == IntType || c.Type == FloatType || c.Type == StringType {
+		subject := "value"
+		if c.Type == StringType {
+			subject = "length"
+		}
+		// integers are compared exactly, as float64 values above 2^53 are rounded
+		var shown any = number
+		compare := func(bound float64) int { return cmp.Compare(number, bound) }
+		if c.Type == IntType {
+			shown = integer
+			compare = func(bound float64) int { return compareIntBound(integer, bound) }
+		}
+		if c.Min != nil && compare(*c.Min) < 0 {
+			return fmt.Sprintf("%s %v is less than minimum %v", subject, shown, *c.Min)
+		}
+		if c.Max != nil && compare(*c.Max) > 0 {
+			return fmt.Sprintf("%s %v is greater than maximum %v", subject, shown, *c.Max)
+		}
+	}
+	if c.unique != nil {
+		if c.unique[value] {
+			return fmt.Sprintf("value %q is not unique", value)
+		}
+		c.unique[value] = true
+	}
+	return ""
+}
+
+// compareIntBound compares an integer with a bound, exactly if the bound is an integer in the int64 range.
+func compareIntBound(i int64, bound float64) int {
+	if bound == math.Trunc(bound) && bound >= math.MinInt64 && bound < math.MaxInt64 {
+		return cmp.Compare(i, int64(bound))
+	}
+	return cmp.Compare(float64(i), bound)
+}
+
+// MaxReportErrors is the maximum number of errors retained in a ValidationReport.
+const MaxReportErrors = 100
+
+// ValidationReport summarizes the validation of a CSV file.
+type ValidationReport struct {
+	// Records is the number of records read, excluding the header.
+	Records int
+	// InvalidRecords is the number of records with a validation or read error.
+	InvalidRecords int
+	// ColumnViolations counts violations by column name.
+	ColumnViolations map[string]int
+	// Errors contains the first MaxReportErrors ValidationErrors and IterationErrors.
+	Errors []error
+}
+
+// Valid reports whether every record is valid.
+func (r *ValidationReport) Valid() bool {
+	return r.InvalidRecords == 0
+}
+
+// ValidateFile validates every record in input against schema and returns a summary ValidationReport.
+// An error is returned if the input cannot be validated, such as when a Required column is missing from the header.
+func ValidateFile(input io.Reader, hasHeader bool, schema *Schema, opts ...ReaderOption) (*ValidationReport, error) {
+	if schema == nil {
+		return nil, errors.New("ValidateFile: schema is required")
+	}
+	noopFunc := func([]string) (struct{}, error) {
+		return struct{}{}, nil
+	}
+	opts = append(slices.Clip(opts), WithSchema(schema), WithErrorPolicy(YieldErrors()))
+	records, err := NewDefaultIterator(input, hasHeader, noopFunc, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ValidateFile: %w", err)
+	}
+
+	report := &ValidationReport{ColumnViolations: make(map[string]int)}
+	for _, err := range records {
+		if err == nil {
+			report.Records++
+			continue
+		}
+
+		// malformed records are reported, other iteration errors prevent validation
+		if !isRecordError(err) {
+			return nil, fmt.Errorf("ValidateFile: %w", err)
+		}
+
+		report.Records++
+		report.InvalidRecords++
+		var ve *ValidationError
+		if errors.As(err, &ve) {
+			for _, v := range ve.Violations {
+				report.ColumnViolations[v.Column]++
+			}
+		}
+		if len(report.Errors) < MaxReportErrors {
+			report.Errors = append(report.Errors, err)
+		}
+	}
+	return report, nil
+}
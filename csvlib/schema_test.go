@@ -0,0 +1,135 @@
+package csvlib
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testSchema returns the schema used in validation test cases.
+func testSchema(t *testing.T) *Schema {
+	t.Helper()
+	return &Schema{Columns: []Column{
+		{Name: "id", Type: IntType, Required: true, Unique: true, Min: Bound(1)},
+		{Name: "email", Pattern: regexp.MustCompile(`^[^@]+@[^@]+$`), Nullable: true},
+		{Name: "status", Enum: []string{"active", "inactive"}},
+		{Name: "score", Type: FloatType, Nullable: true, Max: Bound(100)},
+		{Name: "joined", Type: TimeType, Layout: "2006-01-02", Nullable: true},
+	}}
+}
+
+const schemaCsv = `id,email,status,score,joined
+1,john@example.com,active,99.5,2020-01-02
+2,,inactive,,
+0,jane,unknown,101,01/02/2020
+2,jim@example.com,active,x,
+`
+
+func TestIterator_Schema(t *testing.T) {
+	parsed := 0
+	parseFunc := func(fields []string) ([]string, error) {
+		parsed++
+		return fields, nil
+	}
+
+	iter, err := NewDefaultIterator(strings.NewReader(schemaCsv), true, parseFunc, WithSchema(testSchema(t)))
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+
+	var got [][]Violation
+	for _, err := range iter {
+		var ve *ValidationError
+		if err != nil && !errors.As(err, &ve) {
+			t.Fatalf("expected a ValidationError, got %v", err)
+		}
+		if ve != nil {
+			got = append(got, ve.Violations)
+		}
+	}
+
+	want := [][]Violation{
+		{
+			{Column: "id", Value: "0", Reason: "value 0 is less than minimum 1"},
+			{Column: "email", Value: "jane", Reason: `value "jane" does not match pattern "^[^@]+@[^@]+$"`},
+			{Column: "status", Value: "unknown", Reason: `value "unknown" is not one of ["active" "inactive"]`},
+			{Column: "score", Value: "101", Reason: "value 101 is greater than maximum 100"},
+			{Column: "joined", Value: "01/02/2020", Reason: `value "01/02/2020" is not a valid time`},
+		},
+		{
+			{Column: "id", Value: "2", Reason: `value "2" is not unique`},
+			{Column: "score", Value: "x", Reason: `value "x" is not a valid float`},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("violations found diff (-want +got):\n%s", diff)
+	}
+	if parsed != 2 {
+		t.Errorf("ParseFunc called %d times, want 2", parsed)
+	}
+}
+
+func TestIterator_SchemaMissingRequiredColumn(t *testing.T) {
+	iter, err := NewDefaultIterator(strings.NewReader("email\njohn@example.com\n"), true, slowParseFunc,
+		WithSchema(testSchema(t)))
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	for _, err := range iter {
+		if !errors.Is(err, ErrMissingColumn) {
+			t.Errorf("expected ErrMissingColumn, got %v", err)
+		}
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	report, err := ValidateFile(strings.NewReader(schemaCsv), true, testSchema(t))
+	if err != nil {
+		t.Fatalf("ValidateFile unexpected error %v", err)
+	}
+
+	if report.Valid() {
+		t.Error("expected an invalid report")
+	}
+	if report.Records != 4 || report.InvalidRecords != 2 || len(report.Errors) != 2 {
+		t.Errorf("report records = %d, invalid = %d, errors = %d; want 4, 2, 2",
+			report.Records, report.InvalidRecords, len(report.Errors))
+	}
+	wantViolations := map[string]int{"id": 2, "email": 1, "status": 1, "score": 2, "joined": 1}
+	if diff := cmp.Diff(wantViolations, report.ColumnViolations); diff != "" {
+		t.Errorf("column violations found diff (-want +got):\n%s", diff)
+	}
+
+	if _, err := ValidateFile(strings.NewReader("email\n"), true, testSchema(t)); !errors.Is(err, ErrMissingColumn) {
+		t.Errorf("expected ErrMissingColumn, got %v", err)
+	}
+}
+
+func TestValidateFile_IntBounds(t *testing.T) {
+	schema := &Schema{Columns: []Column{{Name: "id", Type: IntType, Max: Bound(9007199254740992)}}}
+	report, err := ValidateFile(strings.NewReader("id\n9007199254740992\n9007199254740993\n"), true, schema)
+	if err != nil {
+		t.Fatalf("ValidateFile unexpected error %v", err)
+	}
+	if report.InvalidRecords != 1 {
+		t.Fatalf("invalid records = %d, want 1", report.InvalidRecords)
+	}
+	want := "value 9007199254740993 is greater than maximum 9.007199254740992e+15"
+	if got := report.Errors[0].Error(); !strings.Contains(got, want) {
+		t.Errorf("error = %q, want %q", got, want)
+	}
+
+	if _, err := ValidateFile(strings.NewReader("id\n1\n"), true, nil); err == nil {
+		t.Error("ValidateFile expected an error for a nil schema")
+	}
+}
+
+func TestSchema_Invalid(t *testing.T) {
+	schema := &Schema{Columns: []Column{{Name: "id"}, {Name: "id"}}}
+	if _, err := NewDefaultIterator(strings.NewReader(""), true, slowParseFunc, WithSchema(schema)); err == nil {
+		t.Error("expected an error for a schema with duplicate columns")
+	}
+}
@@ -0,0 +1,150 @@
+package csvlib
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrWriterClosed is returned when writing to a SharedWriter which has been closed.
+var ErrWriterClosed = errors.New("writer is closed")
+
+// ErrSequenceGap is returned by SharedWriter.Close when records written with WriteSeq are waiting for a sequence
+// number which was never written.
+var ErrSequenceGap = errors.New("missing sequence number")
+
+// SharedWriter is a goroutine-safe wrapper for a Writer, for multiple producer goroutines writing to a single output.
+// Each record is written in full before another record is started, so records are never interleaved.
+//
+// Records written with Write are written in the order the calls acquire the SharedWriter. Records written with WriteSeq
+// are written in sequence number order, starting at 0, regardless of the order in which producers finish. Records
+// written with Write are not ordered relative to records written with WriteSeq.
+type SharedWriter[T any] struct {
+	mu     sync.Mutex
+	writer Writer[T]
+	// next is the sequence number of the next record written with WriteSeq.
+	next int
+	// pending contains the converted records waiting for an earlier sequence number, by sequence number.
+	pending map[int][]string
+	closed  bool
+	// err is the first error writing a pending record, returned by subsequent calls.
+	err error
+}
+
+// NewSharedWriter returns a SharedWriter which writes to writer.
+// writer may be created by any of the Writer constructors, and must not be used directly once shared.
+// writer's ConvertFunc is called by one goroutine at a time, and need not be safe for concurrent use.
+func NewSharedWriter[T any](writer Writer[T]) *SharedWriter[T] {
+	return &SharedWriter[T]{writer: writer, pending: make(map[int][]string)}
+}
+
+// Write writes the csv record to the output.
+func (w *SharedWriter[T]) Write(inputRecord T) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.writable(); err != nil {
+		return fmt.Errorf("SharedWriter.Write: %w", err)
+	}
+	if err := w.writer.Write(inputRecord); err != nil {
+		return fmt.Errorf("SharedWriter.Write: %w", err)
+	}
+	return nil
+}
+
+// WriteSeq writes the csv record with sequence number seq to the output once the records with sequence numbers 0 to
+// seq-1 have been written. WriteSeq does not block waiting for earlier records: the record is converted and held in
+// memory until its predecessors are written by other calls to WriteSeq.
+//
+// Each sequence number may be written once. A conversion error is returned to the caller and does not consume seq, so
+// the record may be written again. An error writing a held record is returned by the next call to the SharedWriter.
+func (w *SharedWriter[T]) WriteSeq(seq int, inputRecord T) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.writable(); err != nil {
+		return fmt.Errorf("SharedWriter.WriteSeq: %w", err)
+	}
+	if _, ok := w.pending[seq]; ok || seq < w.next {
+		return fmt.Errorf("SharedWriter.WriteSeq: sequence number %d has already been written", seq)
+	}
+
+	csvFields, err := w.writer.convert(inputRecord)
+	if err != nil {
+		return fmt.Errorf("SharedWriter.WriteSeq: %w", err)
+	}
+	w.pending[seq] = csvFields
+
+	for {
+		csvFields, ok := w.pending[w.next]
+		if !ok {
+			return nil
+		}
+		delete(w.pending, w.next)
+		w.next++
+		if err := w.writer.writeRow(csvFields); err != nil {
+			w.err = err
+			return fmt.Errorf("SharedWriter.WriteSeq: %w", err)
+		}
+	}
+}
+
+// WriteHeader writes a header to the output csv file.
+func (w *SharedWriter[T]) WriteHeader(headerRecord []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.writable(); err != nil {
+		return fmt.Errorf("SharedWriter.WriteHeader: %w", err)
+	}
+	return w.writer.WriteHeader(headerRecord)
+}
+
+// Flush writes the current buffer to the output.
+func (w *SharedWriter[T]) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.closed {
+		w.writer.Flush()
+	}
+}
+
+// Stats returns the WriterStats of the shared Writer.
+func (w *SharedWriter[T]) Stats() WriterStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.writer.Stats()
+}
+
+// Close waits for in-flight writes to complete and closes the shared Writer. Subsequent writes return an error
+// wrapping ErrWriterClosed.
+//
+// If records written with WriteSeq are still waiting for an earlier sequence number, the held records are discarded,
+// the output of a Writer created with NewFileWriter is aborted, and Close returns an error wrapping ErrSequenceGap.
+func (w *SharedWriter[T]) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if len(w.pending) > 0 {
+		w.writer.Abort()
+		w.writer.Close()
+		return fmt.Errorf("SharedWriter.Close: %d records are waiting for %w %d", len(w.pending), ErrSequenceGap,
+			w.next)
+	}
+	return w.writer.Close()
+}
+
+// writable returns an error if the SharedWriter is closed or a held record could not be written.
+func (w *SharedWriter[T]) writable() error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	return w.err
+}
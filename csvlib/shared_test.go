@@ -0,0 +1,167 @@
+package csvlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSharedWriter_Write(t *testing.T) {
+	var output bytes.Buffer
+	writer, err := NewWriter(&output, customRecordConvertFunc, WithBufferSize(DefaultBufferSize))
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+	shared := NewSharedWriter(writer)
+
+	const producers, records = 8, 500
+	// long quoted values span buffer flushes, exposing interleaved writes
+	value := strings.Repeat("x,", 200)
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range records {
+				rec := CustomRecord{FirstName: fmt.Sprintf("%d-%d", p, i), LastName: value}
+				if err := shared.Write(rec); err != nil {
+					t.Errorf("Write unexpected error %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := shared.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+
+	iterator, err := NewDefaultIterator(&output, false, customRecordParseFunc)
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	seen := make(map[string]bool)
+	for rec, err := range iterator {
+		if err != nil {
+			t.Fatalf("iterator unexpected error %v", err)
+		}
+		if rec.Data.LastName != value {
+			t.Fatalf("record %s has interleaved value %q", rec.Data.FirstName, rec.Data.LastName)
+		}
+		seen[rec.Data.FirstName] = true
+	}
+	if len(seen) != producers*records {
+		t.Errorf("found %d distinct records, want %d", len(seen), producers*records)
+	}
+	if stats := shared.Stats(); stats.Rows != producers*records {
+		t.Errorf("Stats rows = %d, want %d", stats.Rows, producers*records)
+	}
+}
+
+func TestSharedWriter_WriteSeq(t *testing.T) {
+	var output bytes.Buffer
+	writer, err := NewWriter(&output, customRecordConvertFunc)
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+	shared := NewSharedWriter(writer)
+	if err := shared.WriteHeader([]string{"first", "last"}); err != nil {
+		t.Fatalf("WriteHeader unexpected error %v", err)
+	}
+
+	const records = 1000
+	var want strings.Builder
+	want.WriteString("first,last\n")
+	for i := range records {
+		fmt.Fprintf(&want, "%d,x\n", i)
+	}
+
+	var wg sync.WaitGroup
+	for _, seq := range rand.Perm(records) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := shared.WriteSeq(seq, CustomRecord{FirstName: fmt.Sprint(seq), LastName: "x"}); err != nil {
+				t.Errorf("WriteSeq unexpected error %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := shared.WriteSeq(5, CustomRecord{}); err == nil {
+		t.Error("WriteSeq expected an error for a repeated sequence number")
+	}
+	if err := shared.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+	if got := output.String(); got != want.String() {
+		t.Errorf("WriteSeq output is not in sequence order")
+	}
+}
+
+func TestSharedWriter_Close(t *testing.T) {
+	var output bytes.Buffer
+	writer, err := NewWriter(&output, customRecordConvertFunc)
+	if err != nil {
+		t.Fatalf("NewWriter unexpected error %v", err)
+	}
+	shared := NewSharedWriter(writer)
+
+	// writes racing Close either complete before Close or fail
+	var written sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := range 100 {
+		written.Add(1)
+		go func() {
+			defer written.Done()
+			err := shared.Write(CustomRecord{FirstName: fmt.Sprint(i)})
+			if err != nil && !errors.Is(err, ErrWriterClosed) {
+				t.Errorf("Write unexpected error %v", err)
+			}
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	if err := shared.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+	written.Wait()
+
+	if got := strings.Count(output.String(), "\n"); got != succeeded {
+		t.Errorf("output has %d records, want %d", got, succeeded)
+	}
+	if err := shared.Close(); err != nil {
+		t.Errorf("second Close unexpected error %v", err)
+	}
+}
+
+func TestSharedWriter_SequenceGap(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.csv")
+	writer, err := NewFileWriter(path, customRecordConvertFunc)
+	if err != nil {
+		t.Fatalf("NewFileWriter unexpected error %v", err)
+	}
+	shared := NewSharedWriter(writer)
+
+	for _, seq := range []int{0, 2, 3} {
+		if err := shared.WriteSeq(seq, CustomRecord{FirstName: fmt.Sprint(seq)}); err != nil {
+			t.Fatalf("WriteSeq unexpected error %v", err)
+		}
+	}
+	if err := shared.Close(); !errors.Is(err, ErrSequenceGap) {
+		t.Errorf("Close error = %v, want ErrSequenceGap", err)
+	}
+	assertDirEntries(t, dir, 0)
+	if err := shared.WriteSeq(1, CustomRecord{}); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("WriteSeq error = %v, want ErrWriterClosed", err)
+	}
+}
@@ -0,0 +1,501 @@
+package csvlib
+
+import (
+	"cmp"
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRunBytes is the default approximate memory used to sort records before they are spilled to disk.
+const DefaultMaxRunBytes = 64 << 20
+
+// maxMergeRuns is the maximum number of run files merged at once, bounding the number of open files.
+const maxMergeRuns = 64
+
+// SortKey is a column used to order records.
+type SortKey struct {
+	// Column is the header column name.
+	Column string
+	// Type determines how values are compared. Empty values are ordered before other values.
+	Type ColumnType
+	// Layout is the time layout for TimeType columns. time.RFC3339 is used if Layout is empty.
+	Layout string
+	// Descending reverses the order of the column's values.
+	Descending bool
+}
+
+// SortSpec configures SortFile.
+type SortSpec struct {
+	// Keys lists the columns used to order records, in order of precedence. Records with equal keys retain their
+	// input order.
+	Keys []SortKey
+	// Unique drops records with the same key values as a preceding record, keeping the first record in input order.
+	Unique bool
+	// MaxRunBytes is the approximate memory used to sort records before they are written to a temporary run file.
+	// DefaultMaxRunBytes is used if MaxRunBytes <= 0.
+	MaxRunBytes int64
+	// TempDir is the directory for temporary run files. os.TempDir is used if TempDir is empty.
+	TempDir string
+	// WriterOptions configure the output. Output is written with the input's delimiter unless overridden.
+	WriterOptions []WriterOption
+}
+
+// SortFile sorts the records in input by spec's keys and writes them, following the header, to output.
+// Input larger than MaxRunBytes is sorted in runs which are written to temporary files and merged. Temporary files are
+// removed before SortFile returns.
+//
+// input must have a header. Record errors stop the sort and are returned, unless excluded with an ErrorPolicy such as
+// SkipErrors.
+func SortFile(input io.Reader, output io.Writer, spec SortSpec, opts ...ReaderOption) error {
+	if len(spec.Keys) == 0 {
+		return errors.New("SortFile: at least one sort key is required")
+	}
+	for _, k := range spec.Keys {
+		if k.Type < StringType || k.Type > TimeType {
+			return fmt.Errorf("SortFile: sort key %q has invalid type %s", k.Column, k.Type)
+		}
+	}
+	if spec.MaxRunBytes <= 0 {
+		spec.MaxRunBytes = DefaultMaxRunBytes
+	}
+
+	cfg, err := newReaderConfig(DefaultBufferSize, opts)
+	if err != nil {
+		return fmt.Errorf("SortFile: %w", err)
+	}
+	writerOpts := append([]WriterOption{WithDelimiter(cfg.comma)}, spec.WriterOptions...)
+
+	s := &sorter{spec: spec}
+	defer s.removeRuns()
+
+	records, err := iterator(input, true, DefaultBufferSize, s.bind, s.parse, opts)
+	if err != nil {
+		return fmt.Errorf("SortFile: %w", err)
+	}
+
+	var run []sortRecord
+	var runBytes int64
+	for rec, err := range records {
+		if err != nil {
+			return fmt.Errorf("SortFile: %w", err)
+		}
+		run = append(run, rec.Data)
+		runBytes += rec.Data.size()
+		if runBytes >= spec.MaxRunBytes {
+			if err := s.spill(run); err != nil {
+				return fmt.Errorf("SortFile: %w", err)
+			}
+			run, runBytes = run[:0], 0
+		}
+	}
+	if s.keys == nil {
+		return errors.New("SortFile: input does not have a header")
+	}
+
+	writer, err := NewWriter(output, func(fields []string) ([]string, error) {
+		return fields, nil
+	}, writerOpts...)
+	if err != nil {
+		return fmt.Errorf("SortFile: %w", err)
+	}
+	if err := writer.WriteHeader(s.header); err != nil {
+		return fmt.Errorf("SortFile: %w", err)
+	}
+
+	// input which fits in a single run is written without temporary files
+	var sorted iter.Seq2[sortRecord, error]
+	if len(s.runs) == 0 {
+		s.sortRun(run)
+		sorted = func(yield func(sortRecord, error) bool) {
+			for _, r := range run {
+				if !yield(r, nil) {
+					return
+				}
+			}
+		}
+	} else {
+		if len(run) > 0 {
+			if err := s.spill(run); err != nil {
+				return fmt.Errorf("SortFile: %w", err)
+			}
+		}
+		sorted = s.merge()
+	}
+
+	var previous *sortRecord
+	for r, err := range sorted {
+		if err != nil {
+			return fmt.Errorf("SortFile: %w", err)
+		}
+		if spec.Unique && previous != nil && s.compare(*previous, r) == 0 {
+			continue
+		}
+		if err := writer.Write(r.fields); err != nil {
+			return fmt.Errorf("SortFile: %w", err)
+		}
+		previous = &r
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("SortFile: %w", err)
+	}
+	return nil
+}
+
+// sortValue is a typed sort key value.
+type sortValue struct {
+	empty bool
+	text  string
+	// integer is the value of IntType keys, which are compared exactly rather than as float64 values.
+	integer int64
+	number  float64
+	time    time.Time
+}
+
+// sortRecord is a record's fields along with its parsed sort key values.
+type sortRecord struct {
+	fields []string
+	values []sortValue
+}
+
+// size returns the approximate memory used by the record.
+func (r sortRecord) size() int64 {
+	size := int64(64 + 16*len(r.fields) + 64*len(r.values))
+	for _, f := range r.fields {
+		size += int64(len(f))
+	}
+	return size
+}
+
+// boundSortKey is a SortKey bound to its field position.
+type boundSortKey struct {
+	SortKey
+	index int
+}
+
+// sorter sorts records into runs and merges them.
+type sorter struct {
+	spec   SortSpec
+	header []string
+	keys   []boundSortKey
+	// runs are the paths of the run files to be merged, in input order.
+	runs []string
+	// temps are the paths of every temporary file created, including run files which have been merged.
+	temps []string
+}
+
+// bind binds the sort keys to the header.
+func (s *sorter) bind(header []string) error {
+	h := NewHeader(header)
+	keys := make([]boundSortKey, len(s.spec.Keys))
+	for i, k := range s.spec.Keys {
+		index, ok := h.Index(k.Column)
+		if !ok {
+			return fmt.Errorf("%w %q", ErrMissingColumn, k.Column)
+		}
+		if k.Layout == "" {
+			k.Layout = time.RFC3339
+		}
+		keys[i] = boundSortKey{SortKey: k, index: index}
+	}
+	s.header = slices.Clone(header)
+	s.keys = keys
+	return nil
+}
+
+// parse parses the sort key values of a record.
+func (s *sorter) parse(fields []string) (sortRecord, error) {
+	r := sortRecord{fields: fields, values: make([]sortValue, len(s.keys))}
+	for i, k := range s.keys {
+		var value string
+		if k.index < len(fields) {
+			value = fields[k.index]
+		}
+		if value == "" {
+			r.values[i].empty = true
+			continue
+		}
+
+		var err error
+		switch k.Type {
+		case StringType:
+			r.values[i].text = value
+		case IntType:
+			r.values[i].integer, err = strconv.ParseInt(value, 10, 64)
+		case FloatType:
+			r.values[i].number, err = strconv.ParseFloat(value, 64)
+		case BoolType:
+			var b bool
+			b, err = strconv.ParseBool(value)
+			if b {
+				r.values[i].number = 1
+			}
+		case TimeType:
+			r.values[i].time, err = time.Parse(k.Layout, value)
+		}
+		if err != nil {
+			return r, NewColumnParseError(0, k.Column, fmt.Errorf("invalid %s sort key %w", k.Type, err))
+		}
+	}
+	return r, nil
+}
+
+// compare orders records by their sort key values.
+func (s *sorter) compare(a, b sortRecord) int {
+	for i, k := range s.keys {
+		va, vb := a.values[i], b.values[i]
+		var c int
+		switch {
+		case va.empty || vb.empty:
+			c = compareBool(!va.empty, !vb.empty)
+		case k.Type == StringType:
+			c = strings.Compare(va.text, vb.text)
+		case k.Type == IntType:
+			c = cmp.Compare(va.integer, vb.integer)
+		case k.Type == TimeType:
+			c = va.time.Compare(vb.time)
+		default:
+			c = cmp.Compare(va.number, vb.number)
+		}
+		if k.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareBool orders false before true.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
+// sortRun sorts records in place, retaining the input order of records with equal keys.
+func (s *sorter) sortRun(run []sortRecord) {
+	slices.SortStableFunc(run, s.compare)
+}
+
+// spill sorts run and writes it to a temporary run file.
+func (s *sorter) spill(run []sortRecord) error {
+	s.sortRun(run)
+	path, err := s.writeRun(func(yield func(sortRecord, error) bool) {
+		for _, r := range run {
+			if !yield(r, nil) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, path)
+	return nil
+}
+
+// writeRun writes sorted records to a temporary run file and returns its path.
+func (s *sorter) writeRun(records iter.Seq2[sortRecord, error]) (string, error) {
+	file, err := os.CreateTemp(s.spec.TempDir, "csvlib-sort-*.csv")
+	if err != nil {
+		return "", fmt.Errorf("error creating run file %w", err)
+	}
+	s.temps = append(s.temps, file.Name())
+
+	// quoting every field ensures run files are not mistaken for compressed or BOM prefixed input when read
+	writer, err := NewWriter(file, func(r sortRecord) ([]string, error) {
+		return r.fields, nil
+	}, WithQuoteAll())
+	if err == nil {
+		for r, recordErr := range records {
+			if err = recordErr; err == nil {
+				err = writer.Write(r)
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("error writing run file %w", err)
+	}
+	return file.Name(), nil
+}
+
+// removeRuns removes the temporary run files.
+func (s *sorter) removeRuns() {
+	for _, run := range s.temps {
+		os.Remove(run)
+	}
+}
+
+// merge returns an iterator which merges the sorted run files in key order. Records with equal keys are yielded in
+// run order, retaining their input order. Runs are first merged in passes of up to maxMergeRuns runs, until at most
+// maxMergeRuns runs remain, so that the number of open files is bounded.
+func (s *sorter) merge() iter.Seq2[sortRecord, error] {
+	return func(yield func(sortRecord, error) bool) {
+		for len(s.runs) > maxMergeRuns {
+			if err := s.mergePass(); err != nil {
+				yield(sortRecord{}, err)
+				return
+			}
+		}
+		for r, err := range s.mergeRuns(s.runs) {
+			if !yield(r, err) {
+				return
+			}
+		}
+	}
+}
+
+// mergePass merges each consecutive group of up to maxMergeRuns runs into a single run, retaining run order.
+func (s *sorter) mergePass() error {
+	var merged []string
+	for group := range slices.Chunk(s.runs, maxMergeRuns) {
+		if len(group) == 1 {
+			merged = append(merged, group[0])
+			continue
+		}
+		path, err := s.writeRun(s.mergeRuns(group))
+		if err != nil {
+			return err
+		}
+		merged = append(merged, path)
+		for _, run := range group {
+			os.Remove(run)
+		}
+	}
+	s.runs = merged
+	return nil
+}
+
+// mergeRuns returns an iterator which merges the sorted run files in runs in key order. Records with equal keys are
+// yielded in run order.
+func (s *sorter) mergeRuns(runs []string) iter.Seq2[sortRecord, error] {
+	return func(yield func(sortRecord, error) bool) {
+		h := &mergeHeap{sorter: s}
+		var cursors []*mergeCursor
+		defer func() {
+			for _, c := range cursors {
+				c.stop()
+				c.file.Close()
+			}
+		}()
+
+		for i, run := range runs {
+			file, err := os.Open(run)
+			if err != nil {
+				yield(sortRecord{}, fmt.Errorf("error opening run file %w", err))
+				return
+			}
+			records, err := NewDefaultIterator(file, false, s.parse, WithFieldsPerRecord(-1))
+			if err != nil {
+				file.Close()
+				yield(sortRecord{}, err)
+				return
+			}
+			next, stop := iter.Pull2(records)
+			c := &mergeCursor{run: i, file: file, next: next, stop: stop}
+			cursors = append(cursors, c)
+			if err := c.advance(); err != nil {
+				yield(sortRecord{}, err)
+				return
+			}
+			if !c.done {
+				h.cursors = append(h.cursors, c)
+			}
+		}
+
+		heap.Init(h)
+		for h.Len() > 0 {
+			c := h.cursors[0]
+			if !yield(c.current, nil) {
+				return
+			}
+			if err := c.advance(); err != nil {
+				yield(sortRecord{}, err)
+				return
+			}
+			if c.done {
+				heap.Pop(h)
+			} else {
+				heap.Fix(h, 0)
+			}
+		}
+	}
+}
+
+// mergeCursor is the current record of a run file.
+type mergeCursor struct {
+	run     int
+	file    *os.File
+	next    func() (Record[sortRecord], error, bool)
+	stop    func()
+	current sortRecord
+	done    bool
+}
+
+// advance reads the next record of the run.
+func (c *mergeCursor) advance() error {
+	rec, err, ok := c.next()
+	if !ok {
+		c.done = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading run file %w", err)
+	}
+	c.current = rec.Data
+	return nil
+}
+
+// mergeHeap is a heap.Interface of mergeCursors ordered by their current record.
+type mergeHeap struct {
+	sorter  *sorter
+	cursors []*mergeCursor
+}
+
+func (h *mergeHeap) Len() int {
+	return len(h.cursors)
+}
+
+func (h *mergeHeap) Less(i, j int) bool {
+	a, b := h.cursors[i], h.cursors[j]
+	if c := h.sorter.compare(a.current, b.current); c != 0 {
+		return c < 0
+	}
+	return a.run < b.run
+}
+
+func (h *mergeHeap) Swap(i, j int) {
+	h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i]
+}
+
+func (h *mergeHeap) Push(x any) {
+	h.cursors = append(h.cursors, x.(*mergeCursor))
+}
+
+func (h *mergeHeap) Pop() any {
+	last := h.cursors[len(h.cursors)-1]
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return last
+}
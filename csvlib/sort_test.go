@@ -0,0 +1,217 @@
+package csvlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSortFile(t *testing.T) {
+	csvData := "name,age,joined\n" +
+		"bob,30,2024-03-01\n" +
+		"\"ann\nmarie\",25,2023-01-15\n" +
+		"carl,,2022-07-04\n" +
+		"dave,30,2021-12-31\n" +
+		"bob,9,2020-01-01\n"
+
+	tests := map[string]struct {
+		spec SortSpec
+		want string
+	}{
+		"string": {
+			spec: SortSpec{Keys: []SortKey{{Column: "name"}}},
+			want: "name,age,joined\n\"ann\nmarie\",25,2023-01-15\nbob,30,2024-03-01\nbob,9,2020-01-01\n" +
+				"carl,,2022-07-04\ndave,30,2021-12-31\n",
+		},
+		"int with empty values first and stable ties": {
+			spec: SortSpec{Keys: []SortKey{{Column: "age", Type: IntType}}},
+			want: "name,age,joined\ncarl,,2022-07-04\nbob,9,2020-01-01\n\"ann\nmarie\",25,2023-01-15\n" +
+				"bob,30,2024-03-01\ndave,30,2021-12-31\n",
+		},
+		"multiple keys descending": {
+			spec: SortSpec{Keys: []SortKey{
+				{Column: "age", Type: IntType, Descending: true},
+				{Column: "joined", Type: TimeType, Layout: "2006-01-02"},
+			}},
+			want: "name,age,joined\ndave,30,2021-12-31\nbob,30,2024-03-01\n\"ann\nmarie\",25,2023-01-15\n" +
+				"bob,9,2020-01-01\ncarl,,2022-07-04\n",
+		},
+		"unique": {
+			spec: SortSpec{Keys: []SortKey{{Column: "name"}}, Unique: true},
+			want: "name,age,joined\n\"ann\nmarie\",25,2023-01-15\nbob,30,2024-03-01\ncarl,,2022-07-04\n" +
+				"dave,30,2021-12-31\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// sort in memory, and with a run file per record
+			for _, maxRunBytes := range []int64{0, 1} {
+				tc.spec.MaxRunBytes = maxRunBytes
+				tc.spec.TempDir = t.TempDir()
+				var output bytes.Buffer
+				if err := SortFile(strings.NewReader(csvData), &output, tc.spec); err != nil {
+					t.Fatalf("SortFile unexpected error %v", err)
+				}
+				if diff := cmp.Diff(tc.want, output.String()); diff != "" {
+					t.Errorf("SortFile with MaxRunBytes %d found diff (-want +got):\n%s", maxRunBytes, diff)
+				}
+				assertDirEntries(t, tc.spec.TempDir, 0)
+			}
+		})
+	}
+}
+
+func TestSortFile_ExternalMerge(t *testing.T) {
+	ids := rand.Perm(1000)
+	var input strings.Builder
+	input.WriteString("id;group\n")
+	for _, id := range ids {
+		fmt.Fprintf(&input, "%d;%d\n", id, id%7)
+	}
+
+	var output bytes.Buffer
+	spec := SortSpec{
+		Keys:        []SortKey{{Column: "group", Type: IntType}, {Column: "id", Type: FloatType}},
+		MaxRunBytes: 4096,
+		TempDir:     t.TempDir(),
+		// the output delimiter is the input delimiter unless overridden
+		WriterOptions: []WriterOption{WithCRLF()},
+	}
+	if err := SortFile(strings.NewReader(input.String()), &output, spec, WithDelimiter(';')); err != nil {
+		t.Fatalf("SortFile unexpected error %v", err)
+	}
+
+	slices.SortFunc(ids, func(a, b int) int {
+		if c := a%7 - b%7; c != 0 {
+			return c
+		}
+		return a - b
+	})
+	var want strings.Builder
+	want.WriteString("id;group\r\n")
+	for _, id := range ids {
+		fmt.Fprintf(&want, "%d;%d\r\n", id, id%7)
+	}
+	if output.String() != want.String() {
+		t.Errorf("SortFile output is not sorted")
+	}
+	assertDirEntries(t, spec.TempDir, 0)
+}
+
+func TestSortFile_ManyRuns(t *testing.T) {
+	// one record per run requires multiple merge passes, which retain the input order of equal keys
+	const records = maxMergeRuns*maxMergeRuns + 10
+	var input, want strings.Builder
+	input.WriteString("key,seq\n")
+	want.WriteString("key,seq\n")
+	for i := range records {
+		fmt.Fprintf(&input, "%d,%d\n", i%3, i)
+	}
+	for key := range 3 {
+		for i := key; i < records; i += 3 {
+			fmt.Fprintf(&want, "%d,%d\n", key, i)
+		}
+	}
+
+	var output bytes.Buffer
+	spec := SortSpec{Keys: []SortKey{{Column: "key", Type: IntType}}, MaxRunBytes: 1, TempDir: t.TempDir()}
+	if err := SortFile(strings.NewReader(input.String()), &output, spec); err != nil {
+		t.Fatalf("SortFile unexpected error %v", err)
+	}
+	if output.String() != want.String() {
+		t.Errorf("SortFile output is not sorted")
+	}
+	assertDirEntries(t, spec.TempDir, 0)
+}
+
+func TestSortFile_LargeIntKeys(t *testing.T) {
+	// keys above 2^53 are distinct although they are equal as float64 values
+	csvData := "id,name\n9007199254740993,a\n9007199254740992,b\n9007199254740993,c\n"
+	spec := SortSpec{Keys: []SortKey{{Column: "id", Type: IntType}}}
+
+	var output bytes.Buffer
+	if err := SortFile(strings.NewReader(csvData), &output, spec); err != nil {
+		t.Fatalf("SortFile unexpected error %v", err)
+	}
+	want := "id,name\n9007199254740992,b\n9007199254740993,a\n9007199254740993,c\n"
+	if diff := cmp.Diff(want, output.String()); diff != "" {
+		t.Errorf("SortFile found diff (-want +got):\n%s", diff)
+	}
+
+	output.Reset()
+	spec.Unique = true
+	if err := SortFile(strings.NewReader(csvData), &output, spec); err != nil {
+		t.Fatalf("SortFile unexpected error %v", err)
+	}
+	want = "id,name\n9007199254740992,b\n9007199254740993,a\n"
+	if diff := cmp.Diff(want, output.String()); diff != "" {
+		t.Errorf("SortFile with Unique found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestSortFile_Errors(t *testing.T) {
+	csvData := "name,age\nbob,30\nann,x\n"
+	tests := map[string]struct {
+		spec    SortSpec
+		wantErr string
+	}{
+		"no keys": {
+			wantErr: "at least one sort key is required",
+		},
+		"invalid type": {
+			spec:    SortSpec{Keys: []SortKey{{Column: "age", Type: ColumnType(9)}}},
+			wantErr: "invalid type",
+		},
+		"missing column": {
+			spec:    SortSpec{Keys: []SortKey{{Column: "city"}}},
+			wantErr: ErrMissingColumn.Error(),
+		},
+		"invalid key value": {
+			spec:    SortSpec{Keys: []SortKey{{Column: "age", Type: IntType}}},
+			wantErr: `ParseError error in line 3 column "age"`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := SortFile(strings.NewReader(csvData), &bytes.Buffer{}, tc.spec)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("SortFile error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+
+	// invalid records may be skipped
+	var output bytes.Buffer
+	spec := SortSpec{Keys: []SortKey{{Column: "age", Type: IntType}}}
+	if err := SortFile(strings.NewReader(csvData), &output, spec, WithErrorPolicy(SkipErrors())); err != nil {
+		t.Fatalf("SortFile unexpected error %v", err)
+	}
+	if want := "name,age\nbob,30\n"; output.String() != want {
+		t.Errorf("SortFile output = %q, want %q", output.String(), want)
+	}
+
+	// run files are removed when the sort fails
+	dir := t.TempDir()
+	spec = SortSpec{Keys: []SortKey{{Column: "name"}}, MaxRunBytes: 1, TempDir: dir}
+	err := SortFile(failingReader{data: strings.NewReader(csvData)}, &output, spec)
+	var ie *IterationError
+	if !errors.As(err, &ie) {
+		t.Errorf("SortFile error = %v, want IterationError", err)
+	}
+	assertDirEntries(t, dir, 0)
+
+	if err := SortFile(strings.NewReader(""), &output, spec); err == nil {
+		t.Error("SortFile expected error for empty input")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("TempDir was removed: %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package csvlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SplitRule configures how a SplitWriter divides records between files.
+// A SplitWriter with a zero SplitRule writes every record to a single file.
+type SplitRule[T any] struct {
+	// MaxRows, if > 0, limits the number of records in each file, excluding the header.
+	MaxRows int
+	// MaxBytes, if > 0, limits the size of each file before compression. A file exceeds MaxBytes only if a single
+	// record, along with the header, exceeds MaxBytes.
+	MaxBytes int64
+	// PartitionFunc, if not nil, returns the partition key for a record. Each partition is written to its own series
+	// of files.
+	PartitionFunc func(T) (string, error)
+	// FileName, if not nil, returns the path of the file with the 1-based index within a partition. The default
+	// file name is the SplitWriter's path with the partition key, if applicable, and index appended to the file stem,
+	// e.g. "sales-us_east-0001.csv".
+	FileName func(partition string, index int) string
+}
+
+// SplitWriter writes records of type T to a series of files, starting a new file when a file reaches a size or
+// record limit, and writing each partition of records to separate files.
+// Each file begins with the SplitWriter's header, if provided, and is written with NewFileWriter, so that files are
+// only visible under their final name once they are complete.
+// Files remain open until they reach a limit or the SplitWriter is closed, so the number of partitions is limited by
+// the number of files the process may open.
+type SplitWriter[T any] struct {
+	path        string
+	header      []string
+	convertFunc ConvertFunc[T]
+	rule        SplitRule[T]
+	opts        []WriterOption
+	// scratch measures the size of records before they are written.
+	scratch       Writer[T]
+	scratchBuffer *bytes.Buffer
+	partitions    map[string]*splitPartition[T]
+	// owners maps each file path to the partition which created it.
+	owners map[string]string
+	files  []string
+}
+
+// splitPartition is the current file for a partition.
+type splitPartition[T any] struct {
+	writer *Writer[T]
+	// index is the index of the current file within the partition.
+	index int
+	rows  int
+}
+
+// NewSplitWriter creates a new SplitWriter which writes records of type T to files named after path.
+// header, if not nil, is written at the start of each file. opts configure the CSV dialect and writing behavior of
+// each file.
+func NewSplitWriter[T any](path string,
+	header []string,
+	convertFunc ConvertFunc[T],
+	rule SplitRule[T],
+	opts ...WriterOption) (SplitWriter[T], error) {
+
+	if convertFunc == nil {
+		return SplitWriter[T]{}, errors.New("NewSplitWriter: convertFunc is required")
+	}
+	if path == "" && rule.FileName == nil {
+		return SplitWriter[T]{}, errors.New("NewSplitWriter: path is required")
+	}
+	if rule.MaxRows < 0 || rule.MaxBytes < 0 {
+		return SplitWriter[T]{}, fmt.Errorf("NewSplitWriter: invalid limits of %d rows and %d bytes",
+			rule.MaxRows, rule.MaxBytes)
+	}
+
+	cfg, err := newWriterConfig(opts)
+	if err != nil {
+		return SplitWriter[T]{}, fmt.Errorf("NewSplitWriter: %w", err)
+	}
+	// records are measured before compression, and the byte order mark is counted once per file
+	cfg.gzip = false
+	cfg.bom = false
+	scratchBuffer := &bytes.Buffer{}
+
+	return SplitWriter[T]{
+		path:          path,
+		header:        header,
+		convertFunc:   convertFunc,
+		rule:          rule,
+		opts:          opts,
+		scratch:       newWriter(scratchBuffer, convertFunc, cfg),
+		scratchBuffer: scratchBuffer,
+		partitions:    make(map[string]*splitPartition[T]),
+		owners:        make(map[string]string),
+	}, nil
+}
+
+// Files returns the paths of the files created by the SplitWriter, in creation order.
+// Files are complete once the SplitWriter is closed.
+func (w *SplitWriter[T]) Files() []string {
+	return slices.Clone(w.files)
+}
+
+// Write writes the csv record to the current file for its partition, starting a new file if the record would exceed
+// the file's limits.
+func (w *SplitWriter[T]) Write(inputRecord T) error {
+	var key string
+	if w.rule.PartitionFunc != nil {
+		var err error
+		if key, err = w.rule.PartitionFunc(inputRecord); err != nil {
+			return fmt.Errorf("SplitWriter.Write: error partitioning %w", err)
+		}
+	}
+
+	csvFields, err := w.convertFunc(inputRecord)
+	if err != nil {
+		return fmt.Errorf("SplitWriter.Write: error converting %w", err)
+	}
+
+	partition := w.partitions[key]
+	if partition == nil {
+		partition = &splitPartition[T]{}
+		w.partitions[key] = partition
+	}
+	if partition.writer != nil && partition.rows > 0 && w.full(partition, csvFields) {
+		err := partition.writer.Close()
+		partition.writer = nil
+		if err != nil {
+			return fmt.Errorf("SplitWriter.Write: %w", err)
+		}
+	}
+	if partition.writer == nil {
+		if err := w.open(key, partition); err != nil {
+			return fmt.Errorf("SplitWriter.Write: %w", err)
+		}
+	}
+
+	if err := partition.writer.writeRow(csvFields); err != nil {
+		return fmt.Errorf("SplitWriter.Write: %w", err)
+	}
+	partition.rows++
+	return nil
+}
+
+// full reports whether writing csvFields to the partition's current file would exceed its limits.
+func (w *SplitWriter[T]) full(partition *splitPartition[T], csvFields []string) bool {
+	if w.rule.MaxRows > 0 && partition.rows >= w.rule.MaxRows {
+		return true
+	}
+	if w.rule.MaxBytes > 0 {
+		if partition.writer.masker != nil {
+			csvFields = partition.writer.masker.apply(csvFields)
+		}
+		w.scratchBuffer.Reset()
+		w.scratch.writeRecord(csvFields)
+		w.scratch.outputWriter.Flush()
+		return partition.writer.bytesWritten()+int64(w.scratchBuffer.Len()) > w.rule.MaxBytes
+	}
+	return false
+}
+
+// open starts the next file for a partition and writes the header.
+func (w *SplitWriter[T]) open(key string, partition *splitPartition[T]) error {
+	partition.index++
+	partition.rows = 0
+	path := w.fileName(key, partition.index)
+	if owner, ok := w.owners[path]; ok {
+		return fmt.Errorf("partitions %q and %q have the same file name %q", owner, key, path)
+	}
+
+	writer, err := NewFileWriter(path, w.convertFunc, w.opts...)
+	if err != nil {
+		return err
+	}
+	if w.header != nil {
+		if err := writer.WriteHeader(w.header); err != nil {
+			writer.Abort()
+			return err
+		}
+	}
+	w.owners[path] = key
+	w.files = append(w.files, path)
+	partition.writer = &writer
+	return nil
+}
+
+// fileName returns the path of a partition's file.
+func (w *SplitWriter[T]) fileName(key string, index int) string {
+	if w.rule.FileName != nil {
+		return w.rule.FileName(key, index)
+	}
+
+	dir, base := filepath.Split(w.path)
+	stem, ext := base, ""
+	if i := strings.Index(base, "."); i > 0 {
+		stem, ext = base[:i], base[i:]
+	}
+	if w.rule.PartitionFunc != nil {
+		stem += "-" + partitionFileName(key)
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%04d%s", stem, index, ext))
+}
+
+// partitionFileName replaces characters which are not letters, digits, '.', '-' or '_' in a partition key with '_'.
+func partitionFileName(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// Close closes each open file. Files which could not be written are removed.
+func (w *SplitWriter[T]) Close() error {
+	var errs []error
+	for _, key := range w.openPartitions() {
+		partition := w.partitions[key]
+		errs = append(errs, partition.writer.Close())
+		partition.writer = nil
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("SplitWriter.Close: %w", err)
+	}
+	return nil
+}
+
+// Abort discards each open file. Files which reached a limit and were closed are not removed.
+func (w *SplitWriter[T]) Abort() error {
+	var errs []error
+	for _, key := range w.openPartitions() {
+		partition := w.partitions[key]
+		errs = append(errs, partition.writer.Abort())
+		partition.writer = nil
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("SplitWriter.Abort: %w", err)
+	}
+	return nil
+}
+
+// openPartitions returns the keys of partitions with an open file, in sorted order.
+func (w *SplitWriter[T]) openPartitions() []string {
+	var keys []string
+	for key, partition := range w.partitions {
+		if partition.writer != nil {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
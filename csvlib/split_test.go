@@ -0,0 +1,197 @@
+package csvlib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var splitHeader = []string{"first_name", "last_name"}
+
+func splitRecords(n int) []CustomRecord {
+	records := make([]CustomRecord, n)
+	for i := range records {
+		records[i] = CustomRecord{FirstName: fmt.Sprintf("name%d", i+1), LastName: "Doe"}
+	}
+	return records
+}
+
+func readSplitFiles(t *testing.T, files []string) map[string]string {
+	t.Helper()
+	contents := make(map[string]string, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile unexpected error %v", err)
+		}
+		contents[filepath.Base(file)] = string(data)
+	}
+	return contents
+}
+
+func TestSplitWriter(t *testing.T) {
+	lastNamePartition := func(r CustomRecord) (string, error) {
+		return r.LastName, nil
+	}
+
+	tests := map[string]struct {
+		rule    SplitRule[CustomRecord]
+		records []CustomRecord
+		want    map[string]string
+	}{
+		"single file": {
+			records: splitRecords(2),
+			want: map[string]string{
+				"out-0001.csv": "first_name,last_name\nname1,Doe\nname2,Doe\n",
+			},
+		},
+		"max rows": {
+			rule:    SplitRule[CustomRecord]{MaxRows: 2},
+			records: splitRecords(5),
+			want: map[string]string{
+				"out-0001.csv": "first_name,last_name\nname1,Doe\nname2,Doe\n",
+				"out-0002.csv": "first_name,last_name\nname3,Doe\nname4,Doe\n",
+				"out-0003.csv": "first_name,last_name\nname5,Doe\n",
+			},
+		},
+		"max bytes": {
+			// the header is 21 bytes and each record is 10 bytes
+			rule:    SplitRule[CustomRecord]{MaxBytes: 41},
+			records: splitRecords(3),
+			want: map[string]string{
+				"out-0001.csv": "first_name,last_name\nname1,Doe\nname2,Doe\n",
+				"out-0002.csv": "first_name,last_name\nname3,Doe\n",
+			},
+		},
+		"record larger than max bytes": {
+			rule:    SplitRule[CustomRecord]{MaxBytes: 10},
+			records: splitRecords(2),
+			want: map[string]string{
+				"out-0001.csv": "first_name,last_name\nname1,Doe\n",
+				"out-0002.csv": "first_name,last_name\nname2,Doe\n",
+			},
+		},
+		"partitions": {
+			rule: SplitRule[CustomRecord]{MaxRows: 1, PartitionFunc: lastNamePartition},
+			records: []CustomRecord{
+				{FirstName: "John", LastName: "Doe"},
+				{FirstName: "Jane", LastName: "van Dyke"},
+				{FirstName: "Jim", LastName: "Doe"},
+			},
+			want: map[string]string{
+				"out-Doe-0001.csv":      "first_name,last_name\nJohn,Doe\n",
+				"out-Doe-0002.csv":      "first_name,last_name\nJim,Doe\n",
+				"out-van_Dyke-0001.csv": "first_name,last_name\nJane,van Dyke\n",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writer, err := NewSplitWriter(filepath.Join(dir, "out.csv"), splitHeader, customRecordConvertFunc, tc.rule)
+			if err != nil {
+				t.Fatalf("NewSplitWriter unexpected error %v", err)
+			}
+			for _, r := range tc.records {
+				if err := writer.Write(r); err != nil {
+					t.Fatalf("Write unexpected error %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close unexpected error %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, readSplitFiles(t, writer.Files())); diff != "" {
+				t.Errorf("files found diff (-want +got):\n%s", diff)
+			}
+			assertDirEntries(t, dir, len(tc.want))
+		})
+	}
+}
+
+func TestSplitWriter_FileName(t *testing.T) {
+	dir := t.TempDir()
+	rule := SplitRule[CustomRecord]{
+		MaxRows: 1,
+		FileName: func(partition string, index int) string {
+			return filepath.Join(dir, fmt.Sprintf("part%d.csv.gz", index))
+		},
+	}
+	writer, err := NewSplitWriter("", nil, customRecordConvertFunc, rule, WithGzip())
+	if err != nil {
+		t.Fatalf("NewSplitWriter unexpected error %v", err)
+	}
+	for _, r := range splitRecords(2) {
+		if err := writer.Write(r); err != nil {
+			t.Fatalf("Write unexpected error %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error %v", err)
+	}
+
+	want := []string{filepath.Join(dir, "part1.csv.gz"), filepath.Join(dir, "part2.csv.gz")}
+	if diff := cmp.Diff(want, writer.Files()); diff != "" {
+		t.Errorf("Files found diff (-want +got):\n%s", diff)
+	}
+	for i, file := range writer.Files() {
+		records, err := NewFileIterator(file, false, customRecordParseFunc)
+		if err != nil {
+			t.Fatalf("NewFileIterator unexpected error %v", err)
+		}
+		for rec, err := range records {
+			if err != nil || rec.Data.FirstName != fmt.Sprintf("name%d", i+1) {
+				t.Errorf("file %s record = %+v, %v", file, rec.Data, err)
+			}
+		}
+	}
+}
+
+func TestSplitWriter_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.csv")
+
+	if _, err := NewSplitWriter[CustomRecord](path, nil, nil, SplitRule[CustomRecord]{}); err == nil {
+		t.Error("NewSplitWriter expected error for nil convertFunc")
+	}
+	if _, err := NewSplitWriter("", nil, customRecordConvertFunc, SplitRule[CustomRecord]{}); err == nil {
+		t.Error("NewSplitWriter expected error for empty path")
+	}
+	if _, err := NewSplitWriter(path, nil, customRecordConvertFunc, SplitRule[CustomRecord]{MaxRows: -1}); err == nil {
+		t.Error("NewSplitWriter expected error for negative MaxRows")
+	}
+
+	partitionErr := errors.New("test partition error")
+	rule := SplitRule[CustomRecord]{PartitionFunc: func(r CustomRecord) (string, error) {
+		if r.FirstName == "" {
+			return "", partitionErr
+		}
+		return r.FirstName, nil
+	}}
+	writer, err := NewSplitWriter(path, nil, customRecordConvertFunc, rule)
+	if err != nil {
+		t.Fatalf("NewSplitWriter unexpected error %v", err)
+	}
+	if err := writer.Write(CustomRecord{}); !errors.Is(err, partitionErr) {
+		t.Errorf("Write error = %v, want %v", err, partitionErr)
+	}
+	if err := writer.Write(CustomRecord{FirstName: "a/b"}); err != nil {
+		t.Fatalf("Write unexpected error %v", err)
+	}
+	// "a_b" has the same file name as "a/b"
+	if err := writer.Write(CustomRecord{FirstName: "a_b"}); err == nil || !strings.Contains(err.Error(), "same file name") {
+		t.Errorf("Write error = %v, want same file name error", err)
+	}
+
+	// aborting removes open files
+	if err := writer.Abort(); err != nil {
+		t.Fatalf("Abort unexpected error %v", err)
+	}
+	assertDirEntries(t, dir, 0)
+}
@@ -0,0 +1,111 @@
+package csvlib
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// tagName is the struct tag key which maps a struct field to a CSV column.
+	tagName = "csv"
+	// layoutTagName is the struct tag key which specifies the time layout for time.Time fields.
+	layoutTagName = "layout"
+)
+
+// timeType is the reflect.Type of time.Time.
+var timeType = reflect.TypeFor[time.Time]()
+
+// structField describes a struct field mapped to a CSV column with a csv struct tag.
+//
+// The csv tag value is the column name, optionally followed by comma separated options:
+//
+//	FirstName string    `csv:"first_name"`
+//	Nickname  *string   `csv:"nickname,optional"`
+//	Birthday  time.Time `csv:"birthday" layout:"2006-01-02"`
+//	Ignored   string    `csv:"-"`
+type structField struct {
+	// column is the CSV column name.
+	column string
+	// index is the field index sequence used with reflect.Value.FieldByIndex.
+	index []int
+	// optional is true if the column may be absent from the CSV header.
+	optional bool
+	// layout is the time layout used for time.Time fields.
+	layout string
+	// typ is the field's type.
+	typ reflect.Type
+}
+
+// structFields returns the csv tagged fields of struct type t, including the tagged fields of embedded structs.
+func structFields(t reflect.Type) ([]structField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("structFields: %s is not a struct", t)
+	}
+
+	fields, err := collectStructFields(t, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("structFields: %s has no %q tagged fields", t, tagName)
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if seen[f.column] {
+			return nil, fmt.Errorf("structFields: %s has duplicate column %q", t, f.column)
+		}
+		seen[f.column] = true
+	}
+	return fields, nil
+}
+
+// collectStructFields walks the fields of t, prefixing field indexes with parentIndex.
+func collectStructFields(t reflect.Type, parentIndex []int) ([]structField, error) {
+	var fields []structField
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		index := append(append([]int(nil), parentIndex...), i)
+		tag, hasTag := field.Tag.Lookup(tagName)
+
+		if field.Anonymous && !hasTag && field.Type.Kind() == reflect.Struct {
+			embedded, err := collectStructFields(field.Type, index)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+		if !hasTag || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			return nil, fmt.Errorf("collectStructFields: field %s has an empty column name", field.Name)
+		}
+		sf := structField{
+			column: name,
+			index:  index,
+			layout: field.Tag.Get(layoutTagName),
+			typ:    field.Type,
+		}
+		for option := range strings.SplitSeq(options, ",") {
+			switch option {
+			case "":
+			case "optional":
+				sf.optional = true
+			default:
+				return nil, fmt.Errorf("collectStructFields: field %s has unknown tag option %q", field.Name, option)
+			}
+		}
+		if sf.layout == "" {
+			sf.layout = time.RFC3339
+		}
+		fields = append(fields, sf)
+	}
+	return fields, nil
+}
@@ -0,0 +1,118 @@
+package csvlib
+
+import (
+	"iter"
+)
+
+// recordPosition returns a rawRecord with the position of record, for use with baseError.setPosition.
+func recordPosition[T any](record Record[T]) rawRecord {
+	return rawRecord{lineNumber: record.LineNumber, offset: record.Offset, raw: record.Raw, source: record.Source}
+}
+
+// withData returns a Record with the position of record and data.
+func withData[T, U any](record Record[T], data U) Record[U] {
+	return Record[U]{
+		LineNumber: record.LineNumber,
+		Offset:     record.Offset,
+		Raw:        record.Raw,
+		Source:     record.Source,
+		Data:       data,
+	}
+}
+
+// Map returns an iterator which converts the Data of each record with mapFunc, preserving the record's position.
+// An error returned by mapFunc is yielded as a ParseError with the record's position. Errors from records are passed
+// through.
+func Map[T, U any](records iter.Seq2[Record[T], error], mapFunc func(T) (U, error)) iter.Seq2[Record[U], error] {
+	return func(yield func(Record[U], error) bool) {
+		for record, err := range records {
+			if err != nil {
+				if !yield(withData(record, *new(U)), err) {
+					return
+				}
+				continue
+			}
+
+			data, err := mapFunc(record.Data)
+			if err != nil {
+				pe := NewParseError(0, err)
+				pe.setPosition(recordPosition(record))
+				err = pe
+			}
+			if !yield(withData(record, data), err) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns an iterator over the records whose Data satisfies keepFunc. Errors from records are passed through.
+func Filter[T any](records iter.Seq2[Record[T], error], keepFunc func(T) bool) iter.Seq2[Record[T], error] {
+	return func(yield func(Record[T], error) bool) {
+		for record, err := range records {
+			if err == nil && !keepFunc(record.Data) {
+				continue
+			}
+			if !yield(record, err) {
+				return
+			}
+		}
+	}
+}
+
+// maxBatchCapacity is the maximum initial capacity of a batch.
+const maxBatchCapacity = 1024
+
+// Batch returns an iterator which groups records into slices of up to size records. The final batch contains the
+// remaining records. Errors from records are passed through with a nil batch as they occur, so an error is yielded
+// before the batch containing the records which preceded it.
+// Each batch is a new slice which may be retained by the consumer. Batch yields single record batches if size < 1.
+func Batch[T any](records iter.Seq2[Record[T], error], size int) iter.Seq2[[]Record[T], error] {
+	size = max(size, 1)
+	// large batches grow as records are appended rather than being allocated up front
+	capacity := min(size, maxBatchCapacity)
+	return func(yield func([]Record[T], error) bool) {
+		batch := make([]Record[T], 0, capacity)
+		for record, err := range records {
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+				continue
+			}
+
+			batch = append(batch, record)
+			if len(batch) == size {
+				if !yield(batch, nil) {
+					return
+				}
+				batch = make([]Record[T], 0, capacity)
+			}
+		}
+		if len(batch) > 0 {
+			yield(batch, nil)
+		}
+	}
+}
+
+// Take returns an iterator over the first n records. Errors from records are passed through and are not counted.
+// Iteration over records stops once n records have been yielded.
+func Take[T any](records iter.Seq2[Record[T], error], n int) iter.Seq2[Record[T], error] {
+	return func(yield func(Record[T], error) bool) {
+		if n <= 0 {
+			return
+		}
+		taken := 0
+		for record, err := range records {
+			if !yield(record, err) {
+				return
+			}
+			if err == nil {
+				taken++
+				if taken == n {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package csvlib
+
+import (
+	"errors"
+	"iter"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// transformResult records an iterator's yielded values for comparison.
+type transformResult[T any] struct {
+	Line int
+	Data T
+	Err  bool
+}
+
+func collectTransform[T any](t *testing.T, records iter.Seq2[Record[T], error]) []transformResult[T] {
+	t.Helper()
+	var results []transformResult[T]
+	for rec, err := range records {
+		results = append(results, transformResult[T]{Line: rec.LineNumber, Data: rec.Data, Err: err != nil})
+	}
+	return results
+}
+
+// transformCsv has a malformed record in line 4.
+const transformCsv = "id\n1\n2\n3,x\n4\n5\n"
+
+func transformIterator(t *testing.T) iter.Seq2[Record[int], error] {
+	t.Helper()
+	records, err := NewDefaultIterator(strings.NewReader(transformCsv), true, func(fields []string) (int, error) {
+		return strconv.Atoi(fields[0])
+	})
+	if err != nil {
+		t.Fatalf("NewDefaultIterator unexpected error %v", err)
+	}
+	return records
+}
+
+func TestMap(t *testing.T) {
+	mapErr := errors.New("test map error")
+	mapFunc := func(id int) (string, error) {
+		if id == 5 {
+			return "", mapErr
+		}
+		return "id" + strconv.Itoa(id), nil
+	}
+	mapped := Map(transformIterator(t), mapFunc)
+
+	want := []transformResult[string]{
+		{Line: 2, Data: "id1"},
+		{Line: 3, Data: "id2"},
+		{Line: 4, Err: true},
+		{Line: 5, Data: "id4"},
+		{Line: 6, Err: true},
+	}
+	if diff := cmp.Diff(want, collectTransform(t, mapped)); diff != "" {
+		t.Errorf("Map found diff (-want +got):\n%s", diff)
+	}
+
+	// the input is drained by collectTransform, so the mapping error is checked with a fresh iterator
+	var mappedErr error
+	for rec, err := range Map(transformIterator(t), mapFunc) {
+		if rec.LineNumber == 6 {
+			mappedErr = err
+		}
+	}
+	var pe *ParseError
+	if !errors.As(mappedErr, &pe) || !errors.Is(mappedErr, mapErr) || pe.LineNumber() != 6 {
+		t.Errorf("Map error = %v, want ParseError in line 6 wrapping %v", mappedErr, mapErr)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	filtered := Filter(transformIterator(t), func(id int) bool {
+		return id%2 == 1
+	})
+	want := []transformResult[int]{
+		{Line: 2, Data: 1},
+		{Line: 4, Err: true},
+		{Line: 6, Data: 5},
+	}
+	if diff := cmp.Diff(want, collectTransform(t, filtered)); diff != "" {
+		t.Errorf("Filter found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var got [][]int
+	for batch, err := range Batch(transformIterator(t), 2) {
+		if err != nil {
+			got = append(got, nil)
+			continue
+		}
+		var ids []int
+		for _, rec := range batch {
+			ids = append(ids, rec.Data)
+		}
+		got = append(got, ids)
+	}
+	want := [][]int{{1, 2}, nil, {4, 5}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Batch found diff (-want +got):\n%s", diff)
+	}
+
+	// a batch size larger than the input is not allocated up front
+	for batch, err := range Batch(transformIterator(t), math.MaxInt) {
+		if err == nil && len(batch) != 4 {
+			t.Errorf("Batch(math.MaxInt) yielded %d records, want 4", len(batch))
+		}
+	}
+
+	var sizes []int
+	for batch, err := range Batch(transformIterator(t), 0) {
+		if err == nil {
+			sizes = append(sizes, len(batch))
+		}
+	}
+	if diff := cmp.Diff([]int{1, 1, 1, 1}, sizes); diff != "" {
+		t.Errorf("Batch(0) found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestTake(t *testing.T) {
+	want := []transformResult[int]{
+		{Line: 2, Data: 1},
+		{Line: 3, Data: 2},
+		{Line: 4, Err: true},
+		{Line: 5, Data: 4},
+	}
+	if diff := cmp.Diff(want, collectTransform(t, Take(transformIterator(t), 3))); diff != "" {
+		t.Errorf("Take found diff (-want +got):\n%s", diff)
+	}
+	if got := collectTransform(t, Take(transformIterator(t), 0)); len(got) != 0 {
+		t.Errorf("Take(0) yielded %v, want nothing", got)
+	}
+}
+
+func TestTransform_Pipeline(t *testing.T) {
+	evens := Filter(transformIterator(t), func(id int) bool {
+		return id%2 == 0
+	})
+	squares := Map(evens, func(id int) (int, error) {
+		return id * id, nil
+	})
+	var got []Record[int]
+	for batch, err := range Batch(Take(squares, 1), 10) {
+		if err == nil {
+			got = append(got, batch...)
+		}
+	}
+	want := []Record[int]{{LineNumber: 3, Offset: 5, Data: 4}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("pipeline found diff (-want +got):\n%s", diff)
+	}
+}
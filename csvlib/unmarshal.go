@@ -0,0 +1,174 @@
+package csvlib
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// textUnmarshalerType is the reflect.Type of encoding.TextUnmarshaler.
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// decodeFunc decodes a CSV field value into a settable reflect.Value.
+type decodeFunc func(value string, dest reflect.Value) error
+
+// fieldDecoder pairs a tagged struct field with its decodeFunc.
+type fieldDecoder struct {
+	structField
+	decode decodeFunc
+}
+
+// NewStructParseFunc returns a RowParseFunc which builds T from its csv struct tags.
+//
+// T must be a struct type. Supported field types are string, bool, signed and unsigned integers, floats, time.Time
+// (parsed with the field's layout tag, time.RFC3339 by default), types implementing encoding.TextUnmarshaler, and
+// pointers to any of these. Pointer fields are nil when the CSV value is empty.
+//
+// A field which cannot be decoded is returned as a ParseError containing the column name.
+func NewStructParseFunc[T any]() (RowParseFunc[T], error) {
+	decoders, err := structDecoders(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, fmt.Errorf("NewStructParseFunc: %w", err)
+	}
+
+	return func(row Row) (T, error) {
+		var data T
+		target := reflect.ValueOf(&data).Elem()
+
+		for _, d := range decoders {
+			value, ok := row.Lookup(d.column)
+			if !ok {
+				if d.optional {
+					continue
+				}
+				return data, NewColumnParseError(0, d.column, ErrMissingColumn)
+			}
+			if err := d.decode(value, target.FieldByIndex(d.index)); err != nil {
+				return data, NewColumnParseError(0, d.column, err)
+			}
+		}
+		return data, nil
+	}, nil
+}
+
+// NewStructIterator returns a header-aware iterator which builds T from its csv struct tags.
+// Columns for fields which are not tagged as optional are required in the CSV header.
+// See NewStructParseFunc for supported field types.
+func NewStructIterator[T any](input io.Reader, opts ...ReaderOption) (iter.Seq2[Record[T], error], error) {
+	parseFunc, err := NewStructParseFunc[T]()
+	if err != nil {
+		return nil, NewIterationError(0, err)
+	}
+
+	fields, _ := structFields(reflect.TypeFor[T]())
+	var requiredColumns []string
+	for _, f := range fields {
+		if !f.optional {
+			requiredColumns = append(requiredColumns, f.column)
+		}
+	}
+	return NewHeaderIterator(input, requiredColumns, parseFunc, opts...)
+}
+
+// structDecoders returns the fieldDecoders for struct type t.
+func structDecoders(t reflect.Type) ([]fieldDecoder, error) {
+	fields, err := structFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	decoders := make([]fieldDecoder, 0, len(fields))
+	for _, f := range fields {
+		decode, err := newDecodeFunc(f.typ, f.layout)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", f.column, err)
+		}
+		decoders = append(decoders, fieldDecoder{structField: f, decode: decode})
+	}
+	return decoders, nil
+}
+
+// newDecodeFunc returns the decodeFunc for type t.
+func newDecodeFunc(t reflect.Type, layout string) (decodeFunc, error) {
+	switch {
+	case t == timeType:
+		return func(value string, dest reflect.Value) error {
+			parsed, err := time.Parse(layout, value)
+			if err != nil {
+				return err
+			}
+			dest.Set(reflect.ValueOf(parsed))
+			return nil
+		}, nil
+	case t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return func(value string, dest reflect.Value) error {
+			return dest.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+		}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elemDecode, err := newDecodeFunc(t.Elem(), layout)
+		if err != nil {
+			return nil, err
+		}
+		return func(value string, dest reflect.Value) error {
+			if value == "" {
+				dest.SetZero()
+				return nil
+			}
+			elem := reflect.New(t.Elem())
+			if err := elemDecode(value, elem.Elem()); err != nil {
+				return err
+			}
+			dest.Set(elem)
+			return nil
+		}, nil
+	case reflect.String:
+		return func(value string, dest reflect.Value) error {
+			dest.SetString(value)
+			return nil
+		}, nil
+	case reflect.Bool:
+		return func(value string, dest reflect.Value) error {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			dest.SetBool(parsed)
+			return nil
+		}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(value string, dest reflect.Value) error {
+			parsed, err := strconv.ParseInt(value, 10, t.Bits())
+			if err != nil {
+				return err
+			}
+			dest.SetInt(parsed)
+			return nil
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(value string, dest reflect.Value) error {
+			parsed, err := strconv.ParseUint(value, 10, t.Bits())
+			if err != nil {
+				return err
+			}
+			dest.SetUint(parsed)
+			return nil
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(value string, dest reflect.Value) error {
+			parsed, err := strconv.ParseFloat(value, t.Bits())
+			if err != nil {
+				return err
+			}
+			dest.SetFloat(parsed)
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported field type %s", t)
+}
@@ -0,0 +1,134 @@
+package csvlib
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TaggedBase is embedded in TaggedRecord to exercise embedded struct tags.
+type TaggedBase struct {
+	ID uint16 `csv:"id"`
+}
+
+// TaggedRecord exercises the field types supported by struct tag unmarshalling.
+type TaggedRecord struct {
+	TaggedBase
+	Name     string     `csv:"name"`
+	Age      int8       `csv:"age"`
+	Score    float64    `csv:"score"`
+	Active   bool       `csv:"active"`
+	Born     time.Time  `csv:"born" layout:"2006-01-02"`
+	Address  netip.Addr `csv:"address"`
+	Nickname *string    `csv:"nickname"`
+	Missing  *int       `csv:"missing,optional"`
+	Ignored  string     `csv:"-"`
+}
+
+func TestStructIterator(t *testing.T) {
+	csvData := "name,id,age,score,active,born,address,nickname\n" +
+		"John,1,42,9.5,true,1980-02-03,10.0.0.1,Johnny\n" +
+		"Jane,2,37,7.25,false,1985-06-07,::1,\n"
+
+	iter, err := NewStructIterator[TaggedRecord](strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("NewStructIterator unexpected error %v", err)
+	}
+
+	nickname := "Johnny"
+	want := []Record[TaggedRecord]{
+		{
+			LineNumber: 2,
+			Offset:     47,
+			Data: TaggedRecord{
+				TaggedBase: TaggedBase{ID: 1},
+				Name:       "John",
+				Age:        42,
+				Score:      9.5,
+				Active:     true,
+				Born:       time.Date(1980, 2, 3, 0, 0, 0, 0, time.UTC),
+				Address:    netip.MustParseAddr("10.0.0.1"),
+				Nickname:   &nickname,
+			},
+		},
+		{
+			LineNumber: 3,
+			Offset:     93,
+			Data: TaggedRecord{
+				TaggedBase: TaggedBase{ID: 2},
+				Name:       "Jane",
+				Age:        37,
+				Score:      7.25,
+				Born:       time.Date(1985, 6, 7, 0, 0, 0, 0, time.UTC),
+				Address:    netip.MustParseAddr("::1"),
+			},
+		},
+	}
+
+	var got []Record[TaggedRecord]
+	for rec, err := range iter {
+		if err != nil {
+			t.Fatalf("iterator error = %v", err)
+		}
+		got = append(got, rec)
+	}
+	if diff := cmp.Diff(want, got, cmp.Comparer(func(a, b netip.Addr) bool { return a == b })); diff != "" {
+		t.Errorf("iterator found diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestStructIterator_ParseError(t *testing.T) {
+	csvData := "name,id,age,score,active,born,address,nickname\n" +
+		"John,1,420,9.5,true,1980-02-03,10.0.0.1,\n"
+
+	iter, err := NewStructIterator[TaggedRecord](strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("NewStructIterator unexpected error %v", err)
+	}
+
+	for _, err := range iter {
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Fatalf("expected a ParseError, got %v", err)
+		}
+		if pe.LineNumber() != 2 || pe.Column() != "age" {
+			t.Errorf("ParseError line = %d, column = %q; want 2, \"age\"", pe.LineNumber(), pe.Column())
+		}
+	}
+}
+
+func TestStructIterator_MissingRequiredColumn(t *testing.T) {
+	iter, err := NewStructIterator[TaggedRecord](strings.NewReader("name\nJohn\n"))
+	if err != nil {
+		t.Fatalf("NewStructIterator unexpected error %v", err)
+	}
+
+	for _, err := range iter {
+		if !errors.Is(err, ErrMissingColumn) {
+			t.Errorf("expected ErrMissingColumn, got %v", err)
+		}
+	}
+}
+
+func TestNewStructParseFunc_InvalidType(t *testing.T) {
+	type unsupported struct {
+		Values []string `csv:"values"`
+	}
+
+	tests := map[string]func() error{
+		"not-a-struct": func() error { _, err := NewStructParseFunc[string](); return err },
+		"no-tags":      func() error { _, err := NewStructParseFunc[CustomRecord](); return err },
+		"unsupported":  func() error { _, err := NewStructParseFunc[unsupported](); return err },
+	}
+	for name, newFunc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := newFunc(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
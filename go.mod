@@ -2,4 +2,4 @@ module github.com/dixonwhitmire/golib
 
 go 1.24.2
 
-require github.com/google/go-cmp v0.7.0 // indirect
+require github.com/google/go-cmp v0.7.0